@@ -0,0 +1,38 @@
+package bulk
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func Export(w io.Writer, provider providers.ArticleExporter, filter models.ExportFilter, compress bool) error {
+	if err := filter.Invalid(); err != nil {
+		return err
+	}
+
+	if !compress {
+		return export(w, provider, filter)
+	}
+
+	gz := gzip.NewWriter(w)
+	if err := export(gz, provider, filter); err != nil {
+		gz.Close()
+		return err
+	}
+
+	return errors.Wrap(gz.Close(), "failed to write articles")
+}
+
+func export(w io.Writer, provider providers.ArticleExporter, filter models.ExportFilter) error {
+	encoder := json.NewEncoder(w)
+
+	return provider.ExportArticles(filter, func(article *models.Article) error {
+		return errors.Wrap(encoder.Encode(article), "failed to write article")
+	})
+}
@@ -0,0 +1,69 @@
+package bulk_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/bulk"
+	"github.com/eve-qunliu/articles/models"
+)
+
+type exporterMock struct {
+	articles []*models.Article
+	filter   models.ExportFilter
+}
+
+func (m *exporterMock) ExportArticles(filter models.ExportFilter, fn func(*models.Article) error) error {
+	m.filter = filter
+	for _, article := range m.articles {
+		if err := fn(article); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	articles := []*models.Article{
+		{Body: "b1", Date: "2018-06-12", ID: 1, Tags: []models.Tag{"sports"}, Title: "z1", Version: 1},
+		{Body: "b2", Date: "2018-06-13", ID: 2, Tags: []models.Tag{}, Title: "z2", Version: 1},
+	}
+	expected := `{"body":"b1","created_at":"0001-01-01T00:00:00Z","date":"2018-06-12","id":"1","tags":["sports"],"title":"z1","updated_at":"0001-01-01T00:00:00Z","version":1}
+{"body":"b2","created_at":"0001-01-01T00:00:00Z","date":"2018-06-13","id":"2","tags":[],"title":"z2","updated_at":"0001-01-01T00:00:00Z","version":1}
+`
+
+	t.Run("Success - NDJSON", func(t *testing.T) {
+		provider := &exporterMock{articles: articles}
+		var out bytes.Buffer
+
+		err := bulk.Export(&out, provider, models.ExportFilter{From: "2018-06-01", Tag: "sports"}, false)
+
+		assert.NoError(t, err, "unexpected error")
+		assert.Equal(t, expected, out.String(), "output")
+		assert.Equal(t, models.ExportFilter{From: "2018-06-01", Tag: "sports"}, provider.filter, "filter")
+	})
+
+	t.Run("Success - gzip", func(t *testing.T) {
+		provider := &exporterMock{articles: articles}
+		var out bytes.Buffer
+
+		err := bulk.Export(&out, provider, models.ExportFilter{}, true)
+		require.NoError(t, err, "unexpected error")
+
+		reader, err := gzip.NewReader(&out)
+		require.NoError(t, err, "invalid gzip stream")
+		plain, err := ioutil.ReadAll(reader)
+		require.NoError(t, err, "invalid gzip stream")
+		assert.Equal(t, expected, string(plain), "output")
+	})
+
+	t.Run("Failure - invalid date", func(t *testing.T) {
+		err := bulk.Export(&bytes.Buffer{}, &exporterMock{}, models.ExportFilter{To: "20180612"}, false)
+		assert.EqualError(t, err, "export dates must have format YYYY-MM-DD", "error")
+	})
+}
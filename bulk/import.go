@@ -0,0 +1,170 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+const DefaultBatchSize = 500
+
+type Importer struct {
+	BatchSize int
+	Editor    string
+	Provider  providers.ArticleImporter
+	Tags      models.TagPipeline
+	TagLimit  int
+
+	report  *models.ImportReport
+	pending []*models.Article
+	indexes []int
+}
+
+// Import reads a JSON array or NDJSON. An error means the input cannot be read
+// further; the report still covers the articles before it, which may be stored.
+func (im *Importer) Import(r io.Reader) (*models.ImportReport, error) {
+	im.report = &models.ImportReport{Results: make([]models.ImportResult, 0)}
+	im.pending = nil
+	im.indexes = nil
+
+	reader := bufio.NewReader(r)
+
+	array, err := isArray(reader)
+	if err != nil {
+		return im.report, err
+	}
+
+	if array {
+		err = im.readArray(reader)
+	} else {
+		err = im.readLines(reader)
+	}
+
+	im.flush()
+
+	return im.report, err
+}
+
+// A syntax error leaves the rest of the array unreadable.
+func (im *Importer) readArray(reader io.Reader) error {
+	decoder := json.NewDecoder(reader)
+	if _, err := decoder.Token(); err != nil {
+		return errors.Wrap(err, "failed to read articles")
+	}
+
+	for idx := 0; decoder.More(); idx++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			im.report.Results = append(im.report.Results, models.ImportResult{Index: idx})
+			im.fail(idx, err)
+			return errors.Wrapf(err, "failed to read article %d", idx)
+		}
+
+		im.add(idx, raw)
+	}
+
+	return nil
+}
+
+func (im *Importer) readLines(reader *bufio.Reader) error {
+	for idx := 0; ; {
+		line, err := reader.ReadBytes('\n')
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			im.add(idx, json.RawMessage(line))
+			idx++
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to read article %d", idx)
+		}
+	}
+}
+
+func (im *Importer) add(idx int, raw json.RawMessage) {
+	im.report.Results = append(im.report.Results, models.ImportResult{Index: idx})
+
+	article := &models.Article{}
+	if err := json.Unmarshal(raw, article); err != nil {
+		im.fail(idx, err)
+		return
+	}
+
+	if err := article.Normalize(im.TagLimit, im.Tags); err != nil {
+		im.fail(idx, err)
+		return
+	}
+
+	article.Editor = im.Editor
+
+	im.pending = append(im.pending, article)
+	im.indexes = append(im.indexes, idx)
+
+	if len(im.pending) >= im.batchSize() {
+		im.flush()
+	}
+}
+
+func (im *Importer) flush() {
+	if len(im.pending) == 0 {
+		return
+	}
+
+	err := im.Provider.CreateArticles(im.pending)
+
+	for i, article := range im.pending {
+		if err != nil {
+			im.fail(im.indexes[i], err)
+			continue
+		}
+
+		im.report.Results[im.indexes[i]].ID = article.ID
+		im.report.Created++
+	}
+
+	im.pending = nil
+	im.indexes = nil
+}
+
+func (im *Importer) fail(idx int, err error) {
+	im.report.Results[idx].Errors = append(im.report.Results[idx].Errors, err.Error())
+	im.report.Failed++
+}
+
+func (im *Importer) batchSize() int {
+	if im.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+
+	return im.BatchSize
+}
+
+func isArray(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err == io.EOF {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, errors.Wrap(err, "failed to read articles")
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			reader.ReadByte()
+		default:
+			return b[0] == '[', nil
+		}
+	}
+}
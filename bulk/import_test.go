@@ -0,0 +1,116 @@
+package bulk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/bulk"
+	"github.com/eve-qunliu/articles/models"
+)
+
+type importerMock struct {
+	batches [][]*models.Article
+	err     error
+}
+
+func (m *importerMock) CreateArticles(articles []*models.Article) error {
+	m.batches = append(m.batches, articles)
+	for idx, article := range articles {
+		article.ID = int64(100 + idx)
+	}
+	return m.err
+}
+
+func TestImport(t *testing.T) {
+	valid := `{"title":"z1","body":"z3","date":"2018-06-12","tags":["sports"]}`
+	invalid := `{"title":"z1","date":"2018-06-12","tags":["sports"]}`
+	data := []struct {
+		Name            string
+		Payload         string
+		ProviderError   error
+		Batches         int
+		ExpectedReport  models.ImportReport
+		ExpectedFailure bool
+	}{
+		{
+			Name:    "Success - JSON array",
+			Payload: "[" + valid + "," + invalid + "," + valid + "]",
+			Batches: 2,
+			ExpectedReport: models.ImportReport{Created: 2, Failed: 1, Results: []models.ImportResult{
+				{Index: 0, ID: 100},
+				{Index: 1, Errors: []string{"body cannot be empty"}},
+				{Index: 2, ID: 100},
+			}},
+		},
+		{
+			Name:    "Success - NDJSON stream",
+			Payload: valid + "\n" + `{"title":1}` + "\n" + valid + "\n",
+			Batches: 2,
+			ExpectedReport: models.ImportReport{Created: 2, Failed: 1, Results: []models.ImportResult{
+				{Index: 0, ID: 100},
+				{Index: 1, Errors: []string{"json: cannot unmarshal number into Go struct field Article.title of type string"}},
+				{Index: 2, ID: 100},
+			}},
+		},
+		{
+			Name:          "Success - batch failure is reported per article",
+			Payload:       "[" + valid + "]",
+			ProviderError: errors.New("database error"),
+			Batches:       1,
+			ExpectedReport: models.ImportReport{Failed: 1, Results: []models.ImportResult{
+				{Index: 0, Errors: []string{"database error"}},
+			}},
+		},
+		{
+			Name:    "Success - malformed NDJSON line is reported",
+			Payload: valid + "\n" + `{"title":` + "\n\n" + valid,
+			Batches: 2,
+			ExpectedReport: models.ImportReport{Created: 2, Failed: 1, Results: []models.ImportResult{
+				{Index: 0, ID: 100},
+				{Index: 1, Errors: []string{"unexpected end of JSON input"}},
+				{Index: 2, ID: 100},
+			}},
+		},
+		{
+			Name:    "Failure - malformed array keeps the partial report",
+			Payload: "[" + valid + "," + valid + ",",
+			Batches: 2,
+			ExpectedReport: models.ImportReport{Created: 2, Failed: 1, Results: []models.ImportResult{
+				{Index: 0, ID: 100},
+				{Index: 1, ID: 100},
+				{Index: 2, Errors: []string{"unexpected end of JSON input"}},
+			}},
+			ExpectedFailure: true,
+		},
+		{
+			Name:    "Failure - truncated array keeps the partial report",
+			Payload: "[" + valid,
+			Batches: 1,
+			ExpectedReport: models.ImportReport{Created: 1, Failed: 1, Results: []models.ImportResult{
+				{Index: 0, ID: 100},
+				{Index: 1, Errors: []string{"unexpected end of JSON input"}},
+			}},
+			ExpectedFailure: true,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			provider := &importerMock{err: d.ProviderError}
+			importer := &bulk.Importer{BatchSize: 1, Provider: provider, TagLimit: 3}
+
+			report, err := importer.Import(strings.NewReader(d.Payload))
+			if d.ExpectedFailure {
+				assert.Error(t, err, "expected error")
+			} else {
+				assert.NoError(t, err, "unexpected error")
+			}
+
+			assert.Equal(t, d.ExpectedReport, *report, "report")
+			assert.Len(t, provider.batches, d.Batches, "batches")
+		})
+	}
+}
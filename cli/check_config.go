@@ -0,0 +1,26 @@
+package cli
+
+import "fmt"
+
+func checkConfig(env *environment, args []string) error {
+	cfg, err := env.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return err
+	}
+
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	if err = provider.Connection.Ping(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(env.out, "configuration OK")
+	return nil
+}
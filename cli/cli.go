@@ -0,0 +1,100 @@
+// Package cli implements the articles command line.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(env *environment, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve                            start the HTTP server (default)", serve},
+	{"migrate", "migrate up|down [N]|status|force VERSION  manage the database schema", migrate},
+	{"import", "import [-batch-size N] [FILE]    import articles from a JSON array or NDJSON", importArticles},
+	{"export", "export [-from DATE] [-to DATE] [-tag TAG] [-gzip] [-out FILE]  export articles as NDJSON", exportArticles},
+	{"tags", "tags rename FROM TO | merge FROM INTO | normalize | log  administer tags", tags},
+	{"reindex", "reindex                          rebuild tables derived from articles", reindex},
+	{"check-config", "check-config                     validate the configuration and database connection", checkConfig},
+}
+
+// environment is lazy so commands that need no database do not fail without one.
+type environment struct {
+	out      io.Writer
+	config   *config.Config
+	provider *database.DBProvider
+}
+
+func (env *environment) loadConfig() (*config.Config, error) {
+	if env.config != nil {
+		return env.config, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid configuration")
+	}
+
+	env.config = cfg
+	return cfg, nil
+}
+
+func (env *environment) connect() (*database.DBProvider, error) {
+	if env.provider != nil {
+		return env.provider, nil
+	}
+
+	cfg, err := env.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := database.NewProvider(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create data provider")
+	}
+
+	env.provider = provider
+	return provider, nil
+}
+
+// Run starts the HTTP server without arguments.
+func Run(args []string) error {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(&environment{out: os.Stdout}, args)
+		}
+	}
+
+	usage(os.Stderr)
+	return errors.Errorf("unknown command %q", name)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: articles COMMAND [ARGS]")
+	fmt.Fprintln(w, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\n", cmd.usage)
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/migrations"
+)
+
+func migrate(env *environment, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [N]|status|force VERSION")
+	}
+
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	runner, err := migrations.NewRunner(provider.Connection.DB)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "applied %d migrations\n", applied)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return errors.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+
+		reverted, err := runner.Down(n)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "reverted %d migrations\n", reverted)
+	case "status":
+		status, err := runner.Status()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "version %d (dirty: %t), %d pending\n", status.Version, status.Dirty, len(status.Pending))
+		for _, migration := range status.Pending {
+			fmt.Fprintf(env.out, "pending %d_%s\n", migration.Version, migration.Name)
+		}
+	case "force":
+		if len(args) < 2 {
+			return errors.New("usage: migrate force VERSION")
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errors.Errorf("invalid version %q", args[1])
+		}
+
+		return runner.Force(version)
+	default:
+		return errors.Errorf("unknown migrate command %q", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package cli
+
+import "fmt"
+
+func reindex(env *environment, args []string) error {
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	report, err := provider.Reindex()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.out, "removed %d duplicate article tags and %d unused tags\n", report.DuplicateMappings, report.OrphanTags)
+	return nil
+}
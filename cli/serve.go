@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/jobs"
+)
+
+const shutdownTimeout = 30 * time.Second
+
+func serve(env *environment, args []string) error {
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	cfg := env.config
+
+	if cfg.MigrateOnStart {
+		if err = migrate(env, []string{"up"}); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan struct{})
+	var running sync.WaitGroup
+	every := func(interval time.Duration, name string, job func() error) {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			jobs.Every(interval, name, stop, job)
+		}()
+	}
+
+	every(time.Hour, "purge idempotency keys", func() error {
+		_, err := provider.PurgeIdempotencyKeys(time.Now().Add(-cfg.IdempotencyTTL))
+		return err
+	})
+
+	every(cfg.PublishInterval, "publish scheduled articles", func() error {
+		_, err := provider.PublishScheduled(time.Now())
+		return err
+	})
+
+	every(time.Hour, "purge deleted articles", func() error {
+		_, err := provider.PurgeDeleted(time.Now().Add(-cfg.DeletedRetention))
+		return err
+	})
+
+	// Only the streaming routes are left to these timeouts.
+	srv := &http.Server{
+		Handler:           handlers.NewHandler(cfg, provider),
+		Addr:              ":8080",
+		ReadHeaderTimeout: 15 * time.Second,
+		ReadTimeout:       cfg.StreamTimeout,
+		WriteTimeout:      cfg.StreamTimeout,
+		IdleTimeout:       time.Minute,
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err = <-served:
+	case <-signals:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		err = srv.Shutdown(ctx)
+		cancel()
+	}
+
+	// Jobs finish the run they are in before the connection they use is closed.
+	close(stop)
+	running.Wait()
+
+	if closeErr := provider.Connection.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const tagAuditLogLimit = 50
+
+func tags(env *environment, args []string) error {
+	if !(len(args) == 3 || len(args) == 1 && (args[0] == "log" || args[0] == "normalize")) {
+		return errors.New("usage: tags rename FROM TO | tags merge FROM INTO | tags normalize | tags log")
+	}
+
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(args))
+	for idx, arg := range args[1:] {
+		tag, err := env.config.Tags.NormalizeName(arg)
+		if err != nil {
+			return err
+		}
+
+		if tag == "" {
+			return errors.Errorf("tag %q is empty", arg)
+		}
+
+		names[idx+1] = string(tag)
+	}
+
+	switch args[0] {
+	case "rename":
+		if err = provider.RenameTag(names[1], names[2], cliActor()); err != nil {
+			return errors.Wrapf(err, "cannot rename tag %q", args[1])
+		}
+
+		fmt.Fprintf(env.out, "renamed tag %q to %q\n", names[1], names[2])
+	case "merge":
+		if err = provider.MergeTags(names[1], names[2], cliActor()); err != nil {
+			return errors.Wrapf(err, "cannot merge tag %q", args[1])
+		}
+
+		fmt.Fprintf(env.out, "merged tag %q into %q\n", names[1], names[2])
+	case "normalize":
+		report, err := provider.NormalizeTags(cliActor())
+		if report != nil {
+			for _, name := range report.Rejected {
+				fmt.Fprintf(env.out, "tag %q is rejected by the tag pipeline and was left alone\n", name)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "renamed %d tags and merged %d into existing tags\n", report.Renamed, report.Merged)
+	case "log":
+		changes, err := provider.TagAuditLog(tagAuditLogLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			fmt.Fprintf(env.out, "%s  %-6s  %q -> %q  %s\n", change.CreatedAt.Format("2006-01-02 15:04:05"), change.Action,
+				change.From, change.To, change.Actor)
+		}
+	default:
+		return errors.Errorf("unknown tags command %q", args[0])
+	}
+
+	return nil
+}
+
+func cliActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "cli:" + user
+	}
+
+	return "cli"
+}
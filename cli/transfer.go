@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/bulk"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func importArticles(env *environment, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	batchSize := flags.Int("batch-size", bulk.DefaultBatchSize, "number of articles stored per transaction")
+	report := flags.String("report", "", "file to write the per-article results to as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if flags.NArg() > 0 && flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return errors.Wrap(err, "cannot open import file")
+		}
+
+		defer file.Close()
+		r = file
+	}
+
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	importer := &bulk.Importer{BatchSize: *batchSize, Provider: provider, Tags: env.config.Tags, TagLimit: env.config.TagLimit}
+	result, importErr := importer.Import(r)
+
+	// A broken stream still leaves the articles read before it stored.
+	fmt.Fprintf(env.out, "created %d articles, %d failed\n", result.Created, result.Failed)
+
+	if *report != "" {
+		payload, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err = ioutil.WriteFile(*report, payload, 0644); err != nil {
+			return errors.Wrap(err, "cannot write import report")
+		}
+	}
+
+	return importErr
+}
+
+func exportArticles(env *environment, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	from := flags.String("from", "", "only export articles dated on or after `YYYY-MM-DD`")
+	to := flags.String("to", "", "only export articles dated on or before `YYYY-MM-DD`")
+	tag := flags.String("tag", "", "only export articles with this tag")
+	out := flags.String("out", "-", "file to write to, - for stdout")
+	compress := flags.Bool("gzip", false, "gzip the output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	provider, err := env.connect()
+	if err != nil {
+		return err
+	}
+
+	w := env.out
+	if *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return errors.Wrap(err, "cannot create export file")
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	filter := models.ExportFilter{From: *from, Tag: *tag, To: *to}
+	if err = filter.Normalize(env.config.Tags); err != nil {
+		return err
+	}
+
+	return bulk.Export(w, provider, filter, *compress)
+}
@@ -0,0 +1,326 @@
+// Package client is a Go client for the articles HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 200 * time.Millisecond
+)
+
+// Client calls the articles API, retrying idempotent requests that fail with a
+// 5xx or 429 status or do not reach the server.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Token      string
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.HTTPClient = httpClient }
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.HTTPClient.Timeout = timeout }
+}
+
+func WithToken(token string) Option {
+	return func(c *Client) { c.Token = token }
+}
+
+// WithRetries sets how many times a failed request is retried and the initial backoff.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.MaxRetries = maxRetries
+		c.Backoff = backoff
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		MaxRetries: DefaultMaxRetries,
+		Backoff:    DefaultBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// APIError is returned for any response outside the 2xx range.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("articles API: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("articles API: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func StatusCode(err error) int {
+	if apiErr, ok := errors.Cause(err).(*APIError); ok {
+		return apiErr.StatusCode
+	}
+
+	return 0
+}
+
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether a write failed because the article version was stale.
+func IsConflict(err error) bool {
+	status := StatusCode(err)
+	return status == http.StatusConflict || status == http.StatusPreconditionFailed
+}
+
+// CreateArticle creates article under an Idempotency-Key, so retries never duplicate it.
+func (c *Client) CreateArticle(ctx context.Context, article *models.Article) (*models.Article, error) {
+	key, err := idempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+
+	created := &models.Article{}
+	header := http.Header{"Idempotency-Key": {key}}
+	return created, c.do(ctx, http.MethodPost, "/articles", header, article, created)
+}
+
+func (c *Client) GetArticle(ctx context.Context, id int64) (*models.Article, error) {
+	article := &models.Article{}
+	return article, c.do(ctx, http.MethodGet, "/articles/"+strconv.FormatInt(id, 10), nil, nil, article)
+}
+
+// GetArticleBySlug returns the article with slug, following redirects from former slugs.
+func (c *Client) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	article := &models.Article{}
+	return article, c.do(ctx, http.MethodGet, "/articles/by-slug/"+url.PathEscape(slug), nil, nil, article)
+}
+
+// UpdateArticle replaces article, conditioned on article.Version being current.
+func (c *Client) UpdateArticle(ctx context.Context, article *models.Article) (*models.Article, error) {
+	updated := &models.Article{}
+	path := "/articles/" + strconv.FormatInt(article.ID, 10)
+	return updated, c.do(ctx, http.MethodPut, path, nil, article, updated)
+}
+
+func (c *Client) DeleteArticle(ctx context.Context, id int64, version int64) error {
+	path := fmt.Sprintf("/articles/%d?version=%d", id, version)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+func (c *Client) GetTag(ctx context.Context, tag string, date string) (*models.TagArticles, error) {
+	tagArticles := &models.TagArticles{}
+	path := "/tag/" + url.PathEscape(tag) + "/" + url.PathEscape(date)
+	return tagArticles, c.do(ctx, http.MethodGet, path, nil, nil, tagArticles)
+}
+
+// ImportArticles creates articles in bulk. Only ctx bounds the call.
+func (c *Client) ImportArticles(ctx context.Context, articles []*models.Article) (*models.ImportReport, error) {
+	report := &models.ImportReport{}
+	return report, c.streaming().do(ctx, http.MethodPost, "/articles/batch", nil, articles, report)
+}
+
+// ExportArticles calls fn for every article matching filter as it streams in.
+// Only ctx bounds the call.
+func (c *Client) ExportArticles(ctx context.Context, filter models.ExportFilter, fn func(*models.Article) error) error {
+	query := url.Values{}
+	for key, value := range map[string]string{"from": filter.From, "to": filter.To, "tag": filter.Tag} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	resp, err := c.streaming().send(ctx, http.MethodGet, "/export?"+query.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		article := &models.Article{}
+		err = decoder.Decode(article)
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "failed to read exported article")
+		}
+
+		if err = fn(article); err != nil {
+			return err
+		}
+	}
+}
+
+// streaming returns a copy of c without the client timeout.
+func (c *Client) streaming() *Client {
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = 0
+
+	streaming := *c
+	streaming.HTTPClient = &httpClient
+	return &streaming
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, header http.Header, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+	}
+
+	resp, err := c.send(ctx, method, path, header, body)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(out), "failed to decode response")
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, header http.Header, body []byte) (*http.Response, error) {
+	backoff := c.Backoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, method, path, header, body)
+
+		retry := retryable(method, header) && (err != nil || resp.StatusCode >= http.StatusInternalServerError ||
+			resp.StatusCode == http.StatusTooManyRequests)
+		if !retry || attempt >= c.MaxRetries || ctx.Err() != nil {
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to call %s %s", method, path)
+			}
+
+			if resp.StatusCode >= http.StatusBadRequest {
+				return nil, apiError(resp)
+			}
+
+			return resp, nil
+		}
+
+		delay := backoff
+		if resp != nil {
+			delay = retryAfter(resp, backoff)
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		backoff *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method string, path string, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	return c.HTTPClient.Do(req.WithContext(ctx))
+}
+
+func apiError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	problem := struct {
+		Detail string `json:"detail"`
+	}{}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") &&
+		json.Unmarshal(message, &problem) == nil {
+		return &APIError{StatusCode: resp.StatusCode, Message: problem.Detail}
+	}
+
+	return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+}
+
+// retryable reports whether repeating a request cannot apply it twice.
+func retryable(method string, header http.Header) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return header.Get("Idempotency-Key") != ""
+}
+
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return fallback
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func idempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.Wrap(err, "failed to generate idempotency key")
+	}
+
+	return hex.EncodeToString(key), nil
+}
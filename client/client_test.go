@@ -0,0 +1,243 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/client"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func newClient(handler http.HandlerFunc) (*client.Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	return client.New(server.URL, client.WithRetries(2, time.Millisecond), client.WithToken("secret")), server
+}
+
+func TestCreateArticle(t *testing.T) {
+	var keys []string
+	attempts := 0
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		assert.Equal(t, "POST", r.Method, "method")
+		assert.Equal(t, "/articles", r.URL.Path, "path")
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"), "authorization")
+
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		article := &models.Article{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(article), "request body")
+		article.ID = 7
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(article)
+	})
+	defer server.Close()
+
+	article, err := c.CreateArticle(context.Background(), &models.Article{Body: "z3", Date: "2018-06-12", Tags: []models.Tag{"sports"}, Title: "z1"})
+
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, int64(7), article.ID, "id")
+	assert.Equal(t, 2, attempts, "attempts")
+	assert.NotEmpty(t, keys[0], "idempotency key")
+	assert.Equal(t, keys[0], keys[1], "retries reuse the idempotency key")
+}
+
+func TestGetArticle(t *testing.T) {
+	data := []struct {
+		Name          string
+		Status        int
+		Body          string
+		Attempts      int
+		VerifyArticle func(t *testing.T, article *models.Article)
+		VerifyError   func(t *testing.T, err error)
+	}{
+		{
+			Name:     "Success - article found",
+			Status:   http.StatusOK,
+			Body:     `{"id":"123","title":"z1","body":"z3","date":"2018-06-12","tags":["sports"],"version":2}`,
+			Attempts: 1,
+			VerifyArticle: func(t *testing.T, article *models.Article) {
+				assert.Equal(t, models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports"}, Title: "z1", Version: 2}, *article, "article")
+			},
+		},
+		{
+			Name:     "Failure - not found is not retried",
+			Status:   http.StatusNotFound,
+			Attempts: 1,
+			VerifyError: func(t *testing.T, err error) {
+				assert.True(t, client.IsNotFound(err), "not found")
+				assert.EqualError(t, err, "articles API: 404 Not Found", "error")
+			},
+		},
+		{
+			Name:     "Failure - problem detail is reported",
+			Status:   http.StatusBadRequest,
+			Body:     `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request parameters"}`,
+			Attempts: 1,
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "articles API: 400 Bad Request: invalid request parameters", "error")
+			},
+		},
+		{
+			Name:     "Failure - rate limited until retries run out",
+			Status:   http.StatusTooManyRequests,
+			Body:     "slow down",
+			Attempts: 3,
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, http.StatusTooManyRequests, client.StatusCode(err), "status")
+				assert.EqualError(t, err, "articles API: 429 Too Many Requests: slow down", "error")
+			},
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			attempts := 0
+			c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				assert.Equal(t, "/articles/123", r.URL.Path, "path")
+				if strings.HasPrefix(d.Body, "{") && d.Status >= http.StatusBadRequest {
+					w.Header().Set("Content-Type", "application/problem+json")
+				}
+				w.WriteHeader(d.Status)
+				w.Write([]byte(d.Body))
+			})
+			defer server.Close()
+
+			article, err := c.GetArticle(context.Background(), 123)
+
+			assert.Equal(t, d.Attempts, attempts, "attempts")
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			require.NoError(t, err, "unexpected error")
+			d.VerifyArticle(t, article)
+		})
+	}
+}
+
+func TestUpdateArticleConflict(t *testing.T) {
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method, "method")
+		w.WriteHeader(http.StatusConflict)
+	})
+	defer server.Close()
+
+	_, err := c.UpdateArticle(context.Background(), &models.Article{ID: 123, Version: 1})
+
+	assert.True(t, client.IsConflict(err), "conflict")
+}
+
+func TestGetArticleBySlug(t *testing.T) {
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/articles/by-slug/z0" {
+			http.Redirect(w, r, "/articles/by-slug/z1", http.StatusMovedPermanently)
+			return
+		}
+
+		assert.Equal(t, "/articles/by-slug/z1", r.URL.Path, "path")
+		w.Write([]byte(`{"body":"z3","date":"2018-06-12","id":"7","slug":"z1","tags":["sports"],"title":"z1"}`))
+	})
+	defer server.Close()
+
+	article, err := c.GetArticleBySlug(context.Background(), "z0")
+
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, int64(7), article.ID, "id")
+	assert.Equal(t, "z1", article.Slug, "current slug")
+}
+
+func TestGetTag(t *testing.T) {
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tag/sports/20180612", r.URL.Path, "path")
+		w.Write([]byte(`{"articles":["1","2"],"count":2,"related_tags":["music"],"tag":"sports"}`))
+	})
+	defer server.Close()
+
+	tagArticles, err := c.GetTag(context.Background(), "sports", "20180612")
+
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, 2, tagArticles.Count, "count")
+	assert.Equal(t, []string{"music"}, []string(tagArticles.RelatedTags), "related tags")
+}
+
+func TestExportArticles(t *testing.T) {
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "sports", r.URL.Query().Get("tag"), "tag filter")
+		w.Write([]byte("{\"id\":\"1\",\"title\":\"z1\"}\n{\"id\":\"2\",\"title\":\"z2\"}\n"))
+	})
+	defer server.Close()
+
+	var titles []string
+	err := c.ExportArticles(context.Background(), models.ExportFilter{Tag: "sports"}, func(article *models.Article) error {
+		titles = append(titles, article.Title)
+		return nil
+	})
+
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, []string{"z1", "z2"}, titles, "titles")
+}
+
+func TestExportArticlesOutlivesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"id\":\"1\",\"title\":\"z1\"}\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("{\"id\":\"2\",\"title\":\"z2\"}\n"))
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithTimeout(20*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	count := 0
+	err := c.ExportArticles(ctx, models.ExportFilter{}, func(article *models.Article) error {
+		count++
+		return nil
+	})
+
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, 2, count, "articles")
+}
+
+func TestImportArticlesNotRetried(t *testing.T) {
+	attempts := 0
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer server.Close()
+
+	_, err := c.ImportArticles(context.Background(), []*models.Article{{Title: "z1"}})
+
+	assert.Equal(t, http.StatusBadGateway, client.StatusCode(err), "status")
+	assert.Equal(t, 1, attempts, "a POST without an Idempotency-Key is sent once")
+}
+
+func TestImportArticles(t *testing.T) {
+	c, server := newClient(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.True(t, strings.HasPrefix(string(body), "["), "JSON array body")
+		w.Write([]byte(`{"created":1,"failed":0,"results":[{"id":"5","index":0}]}`))
+	})
+	defer server.Close()
+
+	report, err := c.ImportArticles(context.Background(), []*models.Article{{Title: "z1"}})
+
+	require.NoError(t, err, "unexpected error")
+	assert.Equal(t, models.ImportReport{Created: 1, Results: []models.ImportResult{{ID: 5, Index: 0}}}, *report, "report")
+}
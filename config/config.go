@@ -1,17 +1,112 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"net/url"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
 
 type Config struct {
-	DBHost     string `envconfig:"POSTGRES_HOST"`
-	DBName     string `envconfig:"POSTGRES_DB"`
-	DBUser     string `envconfig:"POSTGRES_USER"`
-	DBPassword string `envconfig:"POSTGRES_PASSWORD"`
-	TagLimit   int    `envconfig:"TAG_LIMIT"`
+	AdminToken           string        `envconfig:"ADMIN_TOKEN"`
+	DBHost               string        `envconfig:"POSTGRES_HOST"`
+	DBName               string        `envconfig:"POSTGRES_DB"`
+	DBUser               string        `envconfig:"POSTGRES_USER"`
+	DBPassword           string        `envconfig:"POSTGRES_PASSWORD"`
+	DeletedRetention     time.Duration `envconfig:"DELETED_RETENTION" default:"720h"`
+	FeedLimit            int           `envconfig:"FEED_LIMIT" default:"20"`
+	IdempotencyTTL       time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+	MaxBodyBytes         int64         `envconfig:"MAX_BODY_BYTES" default:"1048576"`
+	MaxStreamBodyBytes   int64         `envconfig:"MAX_STREAM_BODY_BYTES" default:"1073741824"`
+	MigrateOnStart       bool          `envconfig:"MIGRATE_ON_START"`
+	PublicBaseURL        string        `envconfig:"PUBLIC_BASE_URL"`
+	PublishInterval      time.Duration `envconfig:"PUBLISH_INTERVAL" default:"1m"`
+	RequestTimeout       time.Duration `envconfig:"REQUEST_TIMEOUT" default:"15s"`
+	StreamTimeout        time.Duration `envconfig:"STREAM_TIMEOUT" default:"1h"`
+	TagAllowedCharacters string        `envconfig:"TAG_ALLOWED_CHARACTERS" default:"\\p{L}\\p{M}\\p{N}._+#-"`
+	TagBannedWords       []string      `envconfig:"TAG_BANNED_WORDS"`
+	TagLimit             int           `envconfig:"TAG_LIMIT"`
+	TagMaxLength         int           `envconfig:"TAG_MAX_LENGTH" default:"64"`
+	TagNormalizers       []string      `envconfig:"TAG_NORMALIZERS" default:"trim,nfkc,casefold,hyphenate,max-length,charset,banned"`
+	TagSuggestionLimit   int           `envconfig:"TAG_SUGGESTION_LIMIT" default:"10"`
+	// Tags is built by Load from the TAG_* settings.
+	Tags models.TagPipeline `ignored:"true"`
 }
 
 func NewConfig() *Config {
-	cfg := &Config{}
-	envconfig.MustProcess("", cfg)
+	cfg, err := Load()
+	if err != nil {
+		panic(err)
+	}
+
 	return cfg
 }
+
+func Load() (*Config, error) {
+	cfg := &Config{}
+	if err := envconfig.Process("", cfg); err != nil {
+		return cfg, err
+	}
+
+	tags, err := models.NewTagPipeline(cfg.TagNormalizers, models.TagRules{
+		AllowedCharacters: cfg.TagAllowedCharacters,
+		BannedWords:       cfg.TagBannedWords,
+		MaxLength:         cfg.TagMaxLength,
+	})
+
+	cfg.Tags = tags
+	return cfg, errors.Wrap(err, "invalid TAG_NORMALIZERS")
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.DBHost == "" || cfg.DBName == "" || cfg.DBUser == "" {
+		return errors.New("POSTGRES_HOST, POSTGRES_DB and POSTGRES_USER must be set")
+	}
+
+	if cfg.TagLimit <= 0 {
+		return errors.New("TAG_LIMIT must be greater than zero")
+	}
+
+	if cfg.TagMaxLength <= 0 || cfg.TagMaxLength > 255 {
+		return errors.New("TAG_MAX_LENGTH must be between 1 and 255")
+	}
+
+	if cfg.TagSuggestionLimit <= 0 {
+		return errors.New("TAG_SUGGESTION_LIMIT must be greater than zero")
+	}
+
+	if cfg.FeedLimit <= 0 {
+		return errors.New("FEED_LIMIT must be greater than zero")
+	}
+
+	if cfg.IdempotencyTTL <= 0 {
+		return errors.New("IDEMPOTENCY_TTL must be greater than zero")
+	}
+
+	if cfg.MaxBodyBytes <= 0 {
+		return errors.New("MAX_BODY_BYTES must be greater than zero")
+	}
+
+	if cfg.MaxStreamBodyBytes < cfg.MaxBodyBytes {
+		return errors.New("MAX_STREAM_BODY_BYTES must be at least MAX_BODY_BYTES")
+	}
+
+	if cfg.PublishInterval <= 0 {
+		return errors.New("PUBLISH_INTERVAL must be greater than zero")
+	}
+
+	if cfg.DeletedRetention <= 0 {
+		return errors.New("DELETED_RETENTION must be greater than zero")
+	}
+
+	if cfg.PublicBaseURL != "" {
+		if u, err := url.Parse(cfg.PublicBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("PUBLIC_BASE_URL must be an absolute URL such as https://example.com")
+		}
+	}
+
+	return nil
+}
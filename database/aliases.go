@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func (db *DBProvider) TagAliases() ([]models.TagAlias, error) {
+	rows, err := db.Connection.Queryx(`SELECT tag_aliases.alias, tags.name, tag_aliases.created_at
+					   FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id ORDER BY tag_aliases.alias`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve tag aliases")
+	}
+
+	defer rows.Close()
+
+	aliases := make([]models.TagAlias, 0)
+	for rows.Next() {
+		alias := models.TagAlias{}
+		if err = rows.Scan(&alias.Alias, &alias.Tag, &alias.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to read tag aliases")
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	return aliases, errors.Wrap(rows.Err(), "failed to read tag aliases")
+}
+
+func (db *DBProvider) FindTagAlias(name string) (*models.TagAlias, error) {
+	alias := &models.TagAlias{}
+	err := db.Connection.QueryRowx(`SELECT tag_aliases.alias, tags.name, tag_aliases.created_at
+					FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id WHERE tag_aliases.alias = $1`, name).
+		Scan(&alias.Alias, &alias.Tag, &alias.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve tag alias")
+	}
+
+	return alias, nil
+}
+
+// SaveTagAlias points an alias of an alias at the tag the latter resolves to.
+func (db *DBProvider) SaveTagAlias(alias *models.TagAlias) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	canonical, err := canonicalTag(tx, string(alias.Tag))
+	if err != nil {
+		return err
+	}
+
+	ids, err := lockTags(tx, alias.Alias, canonical)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ids[alias.Alias]; ok {
+		return providers.ErrConflict
+	}
+
+	tagID, ok := ids[canonical]
+	if !ok {
+		return providers.ErrNotFound
+	}
+
+	err = tx.QueryRowx(`INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)
+			    ON CONFLICT (alias) DO UPDATE SET tag_id = EXCLUDED.tag_id RETURNING created_at`, alias.Alias, tagID).
+		Scan(&alias.CreatedAt)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to save tag alias")
+	}
+
+	alias.Tag = models.Tag(canonical)
+	return errors.Wrap(tx.Commit(), "failed to commit tag alias")
+}
+
+func (db *DBProvider) DeleteTagAlias(name string) error {
+	result, err := db.Connection.Exec(`DELETE FROM tag_aliases WHERE alias = $1`, name)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete tag alias")
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return providers.ErrNotFound
+	}
+
+	return nil
+}
+
+func canonicalTag(q sqlx.Queryer, name string) (string, error) {
+	var canonical string
+	err := q.QueryRowx(`SELECT tags.name FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id
+			    WHERE tag_aliases.alias = $1`, name).Scan(&canonical)
+
+	if err == sql.ErrNoRows {
+		return name, nil
+	}
+
+	return canonical, errors.Wrap(err, "failed to resolve tag alias")
+}
+
+func resolveAliases(q sqlx.Queryer, articles ...*models.Article) error {
+	tags := uniqArticleTags(articles)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	names := make(pq.StringArray, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, string(tag))
+	}
+
+	rows, err := q.Queryx(`SELECT tag_aliases.alias, tags.name FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id
+			       WHERE tag_aliases.alias = ANY($1)`, names)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve tag aliases")
+	}
+
+	defer rows.Close()
+
+	canonical := make(map[models.Tag]models.Tag)
+	for rows.Next() {
+		var alias, tag models.Tag
+		if err = rows.Scan(&alias, &tag); err != nil {
+			return errors.Wrap(err, "failed to read tag aliases")
+		}
+
+		canonical[alias] = tag
+	}
+
+	if err = rows.Err(); err != nil || len(canonical) == 0 {
+		return errors.Wrap(err, "failed to read tag aliases")
+	}
+
+	for _, article := range articles {
+		seen := make(map[models.Tag]bool, len(article.Tags))
+		resolved := make([]models.Tag, 0, len(article.Tags))
+
+		for _, tag := range article.Tags {
+			if to, ok := canonical[tag]; ok {
+				tag = to
+			}
+
+			if !seen[tag] {
+				seen[tag] = true
+				resolved = append(resolved, tag)
+			}
+		}
+
+		article.Tags = resolved
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func TestSaveTagAlias(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name           string
+		Alias          models.TagAlias
+		MockOperations func(m sqlmock.Sqlmock)
+		Expected       models.TagAlias
+		ExpectedError  error
+	}{
+		{
+			Name:  "Failure - tag not exist",
+			Alias: models.TagAlias{Alias: "nyc", Tag: "new-york"},
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectCanonicalTag(m, "new-york").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "nyc").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "new-york").WillReturnError(sql.ErrNoRows)
+				m.ExpectRollback()
+			},
+			ExpectedError: providers.ErrNotFound,
+		},
+		{
+			Name:  "Failure - alias is a tag",
+			Alias: models.TagAlias{Alias: "nyc", Tag: "new-york"},
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectCanonicalTag(m, "new-york").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "nyc").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+				expectLockTag(m, "new-york").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				m.ExpectRollback()
+			},
+			ExpectedError: providers.ErrConflict,
+		},
+		{
+			Name:  "Success - alias of an alias points at its tag",
+			Alias: models.TagAlias{Alias: "nyc", Tag: "big-apple"},
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectCanonicalTag(m, "big-apple").WillReturnRows(mockedRows([]string{"name"}, []interface{}{"new-york"}))
+				expectLockTag(m, "nyc").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "new-york").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				m.ExpectQuery(`INSERT INTO tag_aliases \(alias, tag_id\) VALUES \(\$1, \$2\)
+					       ON CONFLICT \(alias\) DO UPDATE SET tag_id = EXCLUDED.tag_id RETURNING created_at`).
+					WithArgs("nyc", 2).WillReturnRows(mockedRows([]string{"created_at"}, []interface{}{createdAt}))
+				m.ExpectCommit()
+			},
+			Expected: models.TagAlias{Alias: "nyc", CreatedAt: createdAt, Tag: "new-york"},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.SaveTagAlias(&d.Alias)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.ExpectedError != nil {
+				assert.Equal(t, d.ExpectedError, err, "%s: error", d.Name)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.Expected, d.Alias, "%s: alias", d.Name)
+		})
+	}
+}
+
+func TestDeleteTagAlias(t *testing.T) {
+	testTable := []struct {
+		Name          string
+		Affected      int64
+		ExpectedError error
+	}{
+		{
+			Name:          "Failure - alias not exist",
+			ExpectedError: providers.ErrNotFound,
+		},
+		{
+			Name:     "Success - alias deleted",
+			Affected: 1,
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			mock.ExpectExec(`DELETE FROM tag_aliases WHERE alias = \$1`).WithArgs("nyc").
+				WillReturnResult(sqlmock.NewResult(0, d.Affected))
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.DeleteTagAlias("nyc")
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			assert.Equal(t, d.ExpectedError, err, "%s: error", d.Name)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/eve-qunliu/articles/config"
 	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
 )
 
 type DBProvider struct {
@@ -38,48 +40,288 @@ func (db *DBProvider) connect() error {
 }
 
 func (db *DBProvider) CreateArticle(article *models.Article) error {
-	err := db.Connection.QueryRowx(
-		`INSERT INTO articles (title, body, date) VALUES ($1, $2, $3) RETURNING id`,
+	return retrySlugConflicts(func() error { return db.createArticle(article) }, article)
+}
+
+func (db *DBProvider) createArticle(article *models.Article) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if err = article.Transition(""); err != nil {
+		return err
+	}
+
+	if err = resolveAliases(tx, article); err != nil {
+		return err
+	}
+
+	if err = assignSlug(tx, article, nil); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowx(
+		`INSERT INTO articles (title, body, date, format, body_html, slug, status, publish_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, version, created_at, updated_at`,
 		article.Title,
 		article.Body,
 		article.Date,
-	).Scan(&article.ID)
+		article.Format,
+		article.BodyHTML,
+		article.Slug,
+		article.Status,
+		article.PublishAt,
+	).Scan(&article.ID, &article.Version, &article.CreatedAt, &article.UpdatedAt)
 
 	if err != nil {
 		return errors.Wrap(err, "failed to insert article")
 	}
 
-	var tags *sqlx.Rows
-	if tags, err = db.createTags(article.Tags); err != nil {
+	if err = recordSlugs(tx, article); err != nil {
+		return err
+	}
+
+	if err = recordRevisions(tx, article); err != nil {
+		return err
+	}
+
+	if err = db.createArticleTags(tx, article); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit article")
+}
+
+func (db *DBProvider) CreateArticles(articles []*models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	return retrySlugConflicts(func() error { return db.createArticles(articles) }, articles...)
+}
+
+func (db *DBProvider) createArticles(articles []*models.Article) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if err = resolveAliases(tx, articles...); err != nil {
+		return err
+	}
+
+	taken := make(map[string]bool)
+	for _, article := range articles {
+		if err = article.Transition(""); err != nil {
+			return err
+		}
+
+		if err = assignSlug(tx, article, taken); err != nil {
+			return err
+		}
+	}
+
+	valueIndexes, values := articlesValue(articles)
+	statement := fmt.Sprintf(`INSERT INTO articles (title, body, date, format, body_html, slug, status, publish_at) VALUES %s
+				  RETURNING id, version, created_at, updated_at`, valueIndexes)
+	rows, err := tx.Queryx(statement, values...)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to insert articles")
+	}
+
+	if err = scanCreatedArticles(rows, articles); err != nil {
+		return err
+	}
+
+	if err = recordSlugs(tx, articles...); err != nil {
+		return err
+	}
+
+	if err = recordRevisions(tx, articles...); err != nil {
+		return err
+	}
+
+	if tags := uniqArticleTags(articles); len(tags) > 0 {
+		tagRows, err := db.createTags(tx, tags)
+		if err != nil {
+			return errors.Wrap(err, "failed to create tags")
+		}
+
+		ids, err := toTagIds(tagRows)
+		if err != nil {
+			return err
+		}
+
+		pairs := make([]string, 0, len(articles))
+		for _, article := range articles {
+			if len(article.Tags) > 0 {
+				pairs = append(pairs, articleTagsPairs(article.ID, tagIds(article.Tags, ids)))
+			}
+		}
+
+		if err = db.createArticleTagMap(tx, strings.Join(pairs, ",")); err != nil {
+			return errors.Wrap(err, "failed to map article tags")
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit articles")
+}
+
+func (db *DBProvider) UpdateArticle(article *models.Article) error {
+	return retrySlugConflicts(func() error { return db.updateArticle(article) }, article)
+}
+
+func (db *DBProvider) updateArticle(article *models.Article) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	var status string
+	var publishAt *time.Time
+	err = tx.QueryRowx(`SELECT status, publish_at FROM articles WHERE id = $1 AND version = $2 AND deleted_at IS NULL FOR UPDATE`, article.ID, article.Version).
+		Scan(&status, &publishAt)
+
+	if err == sql.ErrNoRows {
+		return db.versionError(tx, article.ID)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve article status")
+	}
+
+	// An update keeping the stored status keeps its publish_at too.
+	if article.Status == "" && article.PublishAt == nil {
+		article.PublishAt = publishAt
+	}
+
+	if err = article.Transition(status); err != nil {
+		return err
+	}
+
+	if err = resolveAliases(tx, article); err != nil {
+		return err
+	}
+
+	if err = assignSlug(tx, article, nil); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowx(
+		`UPDATE articles SET title = $1, body = $2, date = $3, format = $4, body_html = $5, slug = $6, status = $7,
+		 publish_at = $8 WHERE id = $9 AND version = $10 RETURNING version, created_at, updated_at`,
+		article.Title,
+		article.Body,
+		article.Date,
+		article.Format,
+		article.BodyHTML,
+		article.Slug,
+		article.Status,
+		article.PublishAt,
+		article.ID,
+		article.Version,
+	).Scan(&article.Version, &article.CreatedAt, &article.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to update article")
+	}
+
+	if err = recordSlugs(tx, article); err != nil {
+		return err
+	}
+
+	if err = recordRevisions(tx, article); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM tags_articles WHERE article_id = $1`, article.ID); err != nil {
+		return errors.Wrap(err, "failed to remove article tags")
+	}
+
+	if err = db.createArticleTags(tx, article); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit article")
+}
+
+func (db *DBProvider) DeleteArticle(id string, version int64) error {
+	result, err := db.Connection.Exec(`UPDATE articles SET deleted_at = now()
+					   WHERE id = $1 AND version = $2 AND deleted_at IS NULL`, id, version)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete article")
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return db.versionError(db.Connection, id)
+	}
+
+	return nil
+}
+
+func (db *DBProvider) versionError(q sqlx.Queryer, id interface{}) error {
+	var exists bool
+	err := q.QueryRowx(`SELECT EXISTS(SELECT 1 FROM articles WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to check article version")
+	}
+
+	if !exists {
+		return providers.ErrNotFound
+	}
+
+	return providers.ErrConflict
+}
+
+func (db *DBProvider) createArticleTags(q sqlx.Queryer, article *models.Article) error {
+	if len(article.Tags) == 0 {
+		return nil
+	}
+
+	tags, err := db.createTags(q, article.Tags)
+	if err != nil {
 		return errors.Wrap(err, "failed to create tags")
 	}
 
-	var ids []int64
-	if ids, err = toIds(tags); err != nil {
+	var ids map[models.Tag]int64
+	if ids, err = toTagIds(tags); err != nil {
 		return err
 	}
 
-	return db.createArticleTagMap(article.ID, ids)
+	return db.createArticleTagMap(q, articleTagsPairs(article.ID, tagIds(article.Tags, ids)))
 }
 
-func (db *DBProvider) createTags(tags []models.Tag) (*sqlx.Rows, error) {
+func (db *DBProvider) createTags(q sqlx.Queryer, tags []models.Tag) (*sqlx.Rows, error) {
 	valueIndexes, values := tagsValue(tags)
-	statement := fmt.Sprintf("INSERT INTO tags (name) VALUES %s ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name RETURNING id", valueIndexes)
-	return db.Connection.Queryx(statement, values...)
+	statement := fmt.Sprintf("INSERT INTO tags (name) VALUES %s ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name RETURNING id, name", valueIndexes)
+	return q.Queryx(statement, values...)
 }
 
-func (db *DBProvider) createArticleTagMap(article int64, tags []int64) error {
-	statement := fmt.Sprintf("INSERT INTO tags_articles (article_id, tag_id) VALUES %s", articleTagsPairs(article, tags))
-	return db.Connection.QueryRowx(statement).Err()
+func (db *DBProvider) createArticleTagMap(q sqlx.Queryer, pairs string) error {
+	statement := fmt.Sprintf("INSERT INTO tags_articles (article_id, tag_id, position) VALUES %s", pairs)
+	return q.QueryRowx(statement).Err()
 }
 
 func (db *DBProvider) FindArticle(id string) (*models.Article, error) {
 	article := &models.Article{}
 	var tags pq.StringArray
-	statement := fmt.Sprintf(`SELECT articles.id, articles.title, articles.body, articles.date, array_agg(tags.name)
-				  FROM articles, tags, tags_articles WHERE articles.id = tags_articles.article_id AND
-				  tags.id = tags_articles.tag_id AND articles.id = $1 GROUP BY articles.id`)
-	err := db.Connection.QueryRowx(statement, id).Scan(&article.ID, &article.Title, &article.Body, &article.Date, &tags)
+	statement := fmt.Sprintf(`SELECT articles.id, articles.title, articles.body, articles.date, articles.version,
+				  articles.created_at, articles.updated_at, articles.format, articles.body_html, articles.slug, articles.status,
+				  articles.publish_at, array_remove(array_agg(tags.name ORDER BY tags_articles.position), NULL)
+				  FROM articles LEFT JOIN tags_articles ON articles.id = tags_articles.article_id
+				  LEFT JOIN tags ON tags.id = tags_articles.tag_id
+				  WHERE articles.id = $1 AND articles.deleted_at IS NULL GROUP BY articles.id`)
+	err := db.Connection.QueryRowx(statement, id).Scan(&article.ID, &article.Title, &article.Body, &article.Date,
+		&article.Version, &article.CreatedAt, &article.UpdatedAt, &article.Format, &article.BodyHTML, &article.Slug, &article.Status, &article.PublishAt, &tags)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -94,23 +336,40 @@ func (db *DBProvider) FindArticle(id string) (*models.Article, error) {
 	return article, nil
 }
 
-func (db *DBProvider) FindTag(tag string, date string) (*models.TagArticles, error) {
-	tag = strings.ToLower(tag)
+const latestFirst = "ORDER BY articles.created_at DESC"
+
+const publishedOnly = "articles.status = 'published' AND articles.deleted_at IS NULL"
+
+func (db *DBProvider) FindTag(tag string, date string, includeDescendants bool) (*models.TagArticles, error) {
+	tag, err := canonicalTag(db.Connection, strings.ToLower(tag))
+	if err != nil {
+		return nil, err
+	}
+
 	tagArticle := &models.TagArticles{Tag: tag}
 
+	with := ""
 	fromSubStatement := `FROM tags, articles, tags_articles
 			     WHERE tags.id = tags_articles.tag_id AND articles.id = tags_articles.article_id
-			     AND tags.name = $1 AND articles.date = $2`
+			     AND tags.name = $1 AND articles.date = $2 AND ` + publishedOnly
+	otherTags := "tags.name != $1"
+
+	if includeDescendants {
+		with = tagTreeStatement
+		fromSubStatement = `FROM articles WHERE articles.id IN (SELECT tags_articles.article_id FROM tags_articles, tag_tree
+				     WHERE tags_articles.tag_id = tag_tree.id) AND articles.date = $2 AND ` + publishedOnly
+		otherTags = "tags.id NOT IN (SELECT id FROM tag_tree)"
+	}
 
-	articlesStatement := fmt.Sprintf(`SELECT array_agg(article_ids.id::text)
-					  FROM (SELECT articles.id AS id %s ORDER BY articles.created_at DESC LIMIT 10)
-					  AS article_ids`, fromSubStatement)
+	articlesStatement := fmt.Sprintf(`%s SELECT array_agg(article_ids.id::text)
+					  FROM (SELECT articles.id AS id %s %s LIMIT 10)
+					  AS article_ids`, with, fromSubStatement, latestFirst)
 
-	articlesCountStatement := fmt.Sprintf(`SELECT COUNT(articles.id) %s`, fromSubStatement)
+	articlesCountStatement := fmt.Sprintf(`%s SELECT COUNT(articles.id) %s`, with, fromSubStatement)
 
-	relatedTagsStatement := fmt.Sprintf(`SELECT array_agg(DISTINCT(tags.name)) FROM tags, tags_articles
-					     WHERE tags.name != $1 AND tags.id = tags_articles.tag_id
-					     AND tags_articles.article_id IN (SELECT articles.id %s)`, fromSubStatement)
+	relatedTagsStatement := fmt.Sprintf(`%s SELECT array_agg(DISTINCT(tags.name)) FROM tags, tags_articles
+					     WHERE %s AND tags.id = tags_articles.tag_id
+					     AND tags_articles.article_id IN (SELECT articles.id %s)`, with, otherTags, fromSubStatement)
 
 	statements := []string{articlesStatement, articlesCountStatement, relatedTagsStatement}
 	targets := []interface{}{&tagArticle.Articles, &tagArticle.Count, &tagArticle.RelatedTags}
@@ -2,12 +2,15 @@ package database_test
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -15,10 +18,11 @@ import (
 	"github.com/eve-qunliu/articles/config"
 	"github.com/eve-qunliu/articles/database"
 	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
 )
 
 func TestFindArticle(t *testing.T) {
-	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports", "music"}, Title: "z1"}
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Slug: "z1", Tags: []models.Tag{"sports", "music"}, Title: "z1", Version: 1}
 	testTable := []struct {
 		Name           string
 		Article        models.Article
@@ -83,7 +87,9 @@ func TestFindArticle(t *testing.T) {
 }
 
 func TestCreateArticle(t *testing.T) {
-	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports", "music"}, Title: "z1"}
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Slug: "z1", Status: models.StatusPublished, Tags: []models.Tag{"sports", "music"}, Title: "z1"}
+	aliased := article
+	aliased.Tags = []models.Tag{"nyc", "sports", "new-york"}
 	testTable := []struct {
 		Name           string
 		Article        models.Article
@@ -96,7 +102,11 @@ func TestCreateArticle(t *testing.T) {
 			Article:       article,
 			ExpectedError: errors.New("database error"),
 			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1")
 				expectCreateArticle(m).WillReturnError(err)
+				m.ExpectRollback()
 			},
 			VerifyError: func(t *testing.T, err error) {
 				assert.EqualError(t, err, "failed to insert article: database error", "Error")
@@ -107,8 +117,14 @@ func TestCreateArticle(t *testing.T) {
 			Article:       article,
 			ExpectedError: errors.New("database error"),
 			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1")
 				createArticle(m, article)
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1", article.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRecordRevisions(m, 1)
 				expectCreateTags(m).WillReturnError(err)
+				m.ExpectRollback()
 			},
 			VerifyError: func(t *testing.T, err error) {
 				assert.EqualError(t, err, "failed to create tags: database error", "Error")
@@ -118,9 +134,68 @@ func TestCreateArticle(t *testing.T) {
 			Name:    "Success - create article with tags",
 			Article: article,
 			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1")
 				createArticle(m, article)
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1", article.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRecordRevisions(m, 1)
 				createTags(m, article)
 				createArticleTagMap(m, article)
+				m.ExpectCommit()
+			},
+		},
+		{
+			Name:    "Success - aliases resolved to their tags",
+			Article: aliased,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectResolveAliases(m, []driver.Value{"nyc", "new-york"})
+				assignSlug(m, "z1")
+				article.Tags = []models.Tag{"new-york", "sports"}
+				createArticle(m, article)
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1", article.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRecordRevisions(m, 1)
+				createTags(m, article)
+				createArticleTagMap(m, article)
+				m.ExpectCommit()
+			},
+		},
+		{
+			Name:    "Success - slug claimed by a concurrent write",
+			Article: article,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1")
+				expectCreateArticle(m).WillReturnError(&pq.Error{Code: "23505", Constraint: "articles_slug_key"})
+				m.ExpectRollback()
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1", []driver.Value{"z1", 7, true})
+				article.Slug = "z1-2"
+				createArticle(m, article)
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1-2", article.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRecordRevisions(m, 1)
+				createTags(m, article)
+				createArticleTagMap(m, article)
+				m.ExpectCommit()
+			},
+		},
+		{
+			Name:    "Success - slug taken by another article",
+			Article: article,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1", []driver.Value{"z1", 7, true}, []driver.Value{"z1-2", 8, false})
+				article.Slug = "z1-3"
+				createArticle(m, article)
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1-3", article.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRecordRevisions(m, 1)
+				createTags(m, article)
+				createArticleTagMap(m, article)
+				m.ExpectCommit()
 			},
 		},
 	}
@@ -147,17 +222,299 @@ func TestCreateArticle(t *testing.T) {
 	}
 }
 
-func TestFindTag(t *testing.T) {
+func TestCreateArticleWithoutTags(t *testing.T) {
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Slug: "z1", Status: models.StatusPublished, Tags: []models.Tag{}, Title: "z1", Version: 1}
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	assignSlug(mock, "z1")
+	createArticle(mock, article)
+	expectRecordSlugs(mock, `\(\$1, \$2\)`).WithArgs("z1", article.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecordRevisions(mock, 1)
+	mock.ExpectCommit()
+	selectArticleWithID(mock, "123", article)
+	provider := database.DBProvider{&config.Config{TagLimit: 3}, db}
+
+	created := article
+	require.NoError(t, provider.CreateArticle(&created), "create")
+	found, err := provider.FindArticle("123")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	assert.NoError(t, err, "find")
+	if assert.NotNil(t, found, "article") {
+		assert.Equal(t, article, *found, "article")
+	}
+}
+
+func TestCreateArticles(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		ExpectedError  error
+		MockOperations func(m sqlmock.Sqlmock, err error)
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name:          "Failure - db error",
+			ExpectedError: errors.New("database error"),
+			MockOperations: func(m sqlmock.Sqlmock, err error) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1")
+				assignSlug(m, "z1")
+				expectCreateArticles(m).WillReturnError(err)
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to insert articles: database error", "Error")
+			},
+		},
+		{
+			Name: "Success - create articles with shared tags",
+			MockOperations: func(m sqlmock.Sqlmock, err error) {
+				m.ExpectBegin()
+				expectResolveAliases(m)
+				assignSlug(m, "z1", []driver.Value{"z1", 5, false})
+				assignSlug(m, "z1", []driver.Value{"z1", 5, false})
+				expectCreateArticles(m).WithArgs("z1", "b1", "2018-06-12", "", "", "z1-2", "published", nil, "z1", "b2", "2018-06-13", "", "", "z1-3", "draft", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).
+						AddRow(1, 1, time.Time{}, time.Time{}).
+						AddRow(2, 1, time.Time{}, time.Time{}))
+				expectRecordSlugs(m, `\(\$1, \$2\),\(\$3, \$4\)`).WithArgs("z1-2", 1, "z1-3", 2).WillReturnResult(sqlmock.NewResult(0, 2))
+				expectRecordRevisions(m, 2)
+				expectCreateTags(m).WithArgs("sports", "music").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "sports").AddRow(8, "music"))
+				m.ExpectQuery(`INSERT INTO tags_articles \(article_id, tag_id, position\) VALUES \(1, 7, 0\),\(1, 8, 1\),\(2, 8, 0\)`).
+					WillReturnRows(sqlmock.NewRows([]string{}))
+				m.ExpectCommit()
+			},
+		},
+	}
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock, d.ExpectedError)
+			provider := database.DBProvider{&config.Config{}, db}
+			articles := []*models.Article{
+				{Body: "b1", Date: "2018-06-12", Slug: "z1", Tags: []models.Tag{"sports", "music"}, Title: "z1"},
+				{Body: "b2", Date: "2018-06-13", Slug: "z1", Status: models.StatusDraft, Tags: []models.Tag{"music"}, Title: "z1"},
+			}
+
+			err = provider.CreateArticles(articles)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, int64(2), articles[1].ID, "%s: id", d.Name)
+			assert.Equal(t, "z1-3", articles[1].Slug, "%s: slug", d.Name)
+		})
+	}
+}
+
+func TestUpdateArticle(t *testing.T) {
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Slug: "z1", Tags: []models.Tag{"sports", "music"}, Title: "z1", Version: 2}
+	draft := article
+	draft.Status = models.StatusDraft
+	publishAt := time.Date(2018, 6, 12, 9, 0, 0, 0, time.UTC)
 	testTable := []struct {
 		Name           string
-		Rows           map[string][]interface{}
+		Article        models.Article
 		ExpectedError  error
-		MockOperations func(m sqlmock.Sqlmock, result map[string][]interface{}, err error)
+		MockOperations func(m sqlmock.Sqlmock, err error, article models.Article)
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name:          "Failure - db error",
+			Article:       article,
+			ExpectedError: errors.New("database error"),
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectArticleStatus(m, article).WillReturnRows(sqlmock.NewRows([]string{"status", "publish_at"}).AddRow("published", nil))
+				expectResolveAliases(m)
+				assignSlug(m, "z1")
+				expectUpdateArticle(m).WillReturnError(err)
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to update article: database error", "Error")
+			},
+		},
+		{
+			Name:          "Failure - stale version",
+			Article:       article,
+			ExpectedError: sql.ErrNoRows,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectArticleStatus(m, article).WillReturnError(err)
+				expectArticleExists(m).WithArgs(article.ID).WillReturnRows(mockedRows([]string{"exists"}, []interface{}{true}))
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrConflict, err, "Error")
+			},
+		},
+		{
+			Name:          "Failure - article not exist",
+			Article:       article,
+			ExpectedError: sql.ErrNoRows,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectArticleStatus(m, article).WillReturnError(err)
+				expectArticleExists(m).WithArgs(article.ID).WillReturnRows(mockedRows([]string{"exists"}, []interface{}{false}))
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrNotFound, err, "Error")
+			},
+		},
+		{
+			Name:    "Failure - published article back to draft",
+			Article: draft,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectArticleStatus(m, article).WillReturnRows(sqlmock.NewRows([]string{"status", "publish_at"}).AddRow("published", nil))
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "article cannot move from published to draft", "Error")
+			},
+		},
+		{
+			Name:    "Success - update article with tags",
+			Article: article,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectArticleStatus(m, article).WillReturnRows(sqlmock.NewRows([]string{"status", "publish_at"}).AddRow("published", nil))
+				expectResolveAliases(m)
+				assignSlug(m, "z1", []driver.Value{"z1", 7, true}, []driver.Value{"z1-2", article.ID, true})
+				expectUpdateArticle(m).WithArgs(article.Title, article.Body, article.Date, article.Format, article.BodyHTML, "z1-2",
+					"published", nil, article.ID, article.Version).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "created_at", "updated_at"}).
+						AddRow(article.Version+1, article.CreatedAt, article.UpdatedAt))
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1-2", article.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectRecordRevisions(m, 1)
+				m.ExpectExec(`DELETE FROM tags_articles WHERE article_id = \$1`).WithArgs(article.ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				createTags(m, article)
+				createArticleTagMap(m, article)
+				m.ExpectCommit()
+			},
+		},
+		{
+			Name:    "Success - scheduled article keeps publish_at",
+			Article: article,
+			MockOperations: func(m sqlmock.Sqlmock, err error, article models.Article) {
+				m.ExpectBegin()
+				expectArticleStatus(m, article).WillReturnRows(sqlmock.NewRows([]string{"status", "publish_at"}).AddRow("scheduled", publishAt))
+				expectResolveAliases(m)
+				assignSlug(m, "z1", []driver.Value{"z1", 7, true}, []driver.Value{"z1-2", article.ID, true})
+				expectUpdateArticle(m).WithArgs(article.Title, article.Body, article.Date, article.Format, article.BodyHTML, "z1-2",
+					"scheduled", publishAt, article.ID, article.Version).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "created_at", "updated_at"}).
+						AddRow(article.Version+1, article.CreatedAt, article.UpdatedAt))
+				expectRecordSlugs(m, `\(\$1, \$2\)`).WithArgs("z1-2", article.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectRecordRevisions(m, 1)
+				m.ExpectExec(`DELETE FROM tags_articles WHERE article_id = \$1`).WithArgs(article.ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				createTags(m, article)
+				createArticleTagMap(m, article)
+				m.ExpectCommit()
+			},
+		},
+	}
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock, d.ExpectedError, d.Article)
+			config := config.Config{TagLimit: 3}
+			provider := database.DBProvider{&config, db}
+
+			err = provider.UpdateArticle(&d.Article)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, article.Version+1, d.Article.Version, "%s: version", d.Name)
+			assert.Equal(t, "z1-2", d.Article.Slug, "%s: keeps its current slug", d.Name)
+		})
+	}
+}
+
+func TestDeleteArticle(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
 		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - stale version",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectDeleteArticle(m).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectArticleExists(m).WithArgs("123").WillReturnRows(mockedRows([]string{"exists"}, []interface{}{true}))
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrConflict, err, "Error")
+			},
+		},
+		{
+			Name: "Success - delete article",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectDeleteArticle(m).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.DeleteArticle("123", 2)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+		})
+	}
+}
+
+func TestFindTag(t *testing.T) {
+	testTable := []struct {
+		Name               string
+		Rows               map[string][]interface{}
+		IncludeDescendants bool
+		ExpectedError      error
+		ExpectedTag        string
+		MockOperations     func(m sqlmock.Sqlmock, result map[string][]interface{}, err error)
+		VerifyError        func(t *testing.T, err error)
 	}{
 		{
 			Name: "No data from database",
 			MockOperations: func(m sqlmock.Sqlmock, result map[string][]interface{}, err error) {
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
 				expectLatestArticleswithTag(m, emptyRows()).WillReturnError(err)
 				expectArticlesCount(m, emptyRows()).WillReturnError(err)
 				expectRelatedTags(m, emptyRows()).WillReturnError(err)
@@ -167,6 +524,7 @@ func TestFindTag(t *testing.T) {
 			Name:          "Database error",
 			ExpectedError: errors.New("database error"),
 			MockOperations: func(m sqlmock.Sqlmock, result map[string][]interface{}, err error) {
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
 				expectLatestArticleswithTag(m, emptyRows()).WillReturnError(err)
 			},
 			VerifyError: func(t *testing.T, err error) {
@@ -181,10 +539,36 @@ func TestFindTag(t *testing.T) {
 				"related":  {"{'music','drama'}"},
 			},
 			MockOperations: func(m sqlmock.Sqlmock, result map[string][]interface{}, err error) {
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
 				expectLatestArticleswithTag(m, mockedRows([]string{"ids"}, result["articles"]))
 				expectArticlesCount(m, mockedRows([]string{"count"}, result["count"]))
 				expectRelatedTags(m, mockedRows([]string{"related"}, result["related"]))
 			},
+			ExpectedTag: "sports",
+		},
+		{
+			Name: "Alias resolves to its tag",
+			MockOperations: func(m sqlmock.Sqlmock, result map[string][]interface{}, err error) {
+				expectCanonicalTag(m, "sports").WillReturnRows(mockedRows([]string{"name"}, []interface{}{"sport"}))
+				expectLatestArticleswithTag(m, emptyRows()).WithArgs("sport", "20180101")
+				expectArticlesCount(m, emptyRows()).WithArgs("sport", "20180101")
+				expectRelatedTags(m, emptyRows()).WithArgs("sport", "20180101")
+			},
+			ExpectedTag: "sport",
+		},
+		{
+			Name:               "Descendants included",
+			IncludeDescendants: true,
+			MockOperations: func(m sqlmock.Sqlmock, result map[string][]interface{}, err error) {
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
+				expectTagTree(m, `SELECT array_agg\(article_ids.id::text\) FROM \(SELECT articles.id AS id `+descendantArticles+
+					` ORDER BY articles.created_at DESC LIMIT 10\) AS article_ids`)
+				expectTagTree(m, `SELECT COUNT\(articles.id\) `+descendantArticles)
+				expectTagTree(m, `SELECT array_agg\(DISTINCT\(tags.name\)\) FROM tags, tags_articles
+						  WHERE tags.id NOT IN \(SELECT id FROM tag_tree\) AND tags.id = tags_articles.tag_id
+						  AND tags_articles.article_id IN \(SELECT articles.id `+descendantArticles+`\)`)
+			},
+			ExpectedTag: "sports",
 		},
 	}
 
@@ -198,7 +582,7 @@ func TestFindTag(t *testing.T) {
 			d.MockOperations(mock, d.Rows, d.ExpectedError)
 			provider := database.DBProvider{&config.Config{}, db}
 
-			_, err = provider.FindTag("sports", "20180101")
+			tagArticles, err := provider.FindTag("sports", "20180101", d.IncludeDescendants)
 
 			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
 			if d.VerifyError != nil {
@@ -206,12 +590,18 @@ func TestFindTag(t *testing.T) {
 				return
 			}
 			assert.NoError(t, err, "Error: %s", d.Name)
+			if d.ExpectedTag != "" {
+				assert.Equal(t, d.ExpectedTag, tagArticles.Tag, "%s: tag", d.Name)
+			}
 		})
 	}
 }
 
 func expectArticleQuery(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
-	return m.ExpectQuery(`SELECT articles.id, articles.title, articles.body, articles.date, array_agg\(tags.name\) FROM articles, tags, tags_articles WHERE articles.id = tags_articles.article_id AND tags.id = tags_articles.tag_id AND articles.id = \$1 GROUP BY articles.id`)
+	return m.ExpectQuery(`SELECT articles.id, articles.title, articles.body, articles.date, articles.version,
+				 articles.created_at, articles.updated_at, articles.format, articles.body_html, articles.slug, articles.status, articles.publish_at, array_remove\(array_agg\(tags.name ORDER BY tags_articles.position\), NULL\)
+				 FROM articles LEFT JOIN tags_articles ON articles.id = tags_articles.article_id LEFT JOIN tags ON tags.id = tags_articles.tag_id
+				 WHERE articles.id = \$1 AND articles.deleted_at IS NULL GROUP BY articles.id`)
 }
 
 func selectArticleWithID(m sqlmock.Sqlmock, id string, row models.Article) *sqlmock.ExpectedQuery {
@@ -219,41 +609,97 @@ func selectArticleWithID(m sqlmock.Sqlmock, id string, row models.Article) *sqlm
 }
 
 func asMockArticleRow(article models.Article) *sqlmock.Rows {
-	rows := sqlmock.NewRows([]string{"id", "title", "body", "date", "tags"})
+	rows := sqlmock.NewRows([]string{"id", "title", "body", "date", "version", "created_at", "updated_at", "format", "body_html", "slug", "status", "publish_at", "tags"})
 	var tags []string
 	for _, tag := range article.Tags {
 		tags = append(tags, string(tag))
 	}
-	rows.AddRow(article.ID, article.Title, article.Body, article.Date, fmt.Sprintf("{%s}", strings.Join(tags, ",")))
+	rows.AddRow(article.ID, article.Title, article.Body, article.Date, article.Version, article.CreatedAt, article.UpdatedAt,
+		article.Format, article.BodyHTML, article.Slug, article.Status, article.PublishAt, fmt.Sprintf("{%s}", strings.Join(tags, ",")))
 	return rows
 }
 
 func expectCreateArticle(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
-	return m.ExpectQuery(`INSERT INTO articles \(title, body, date\) VALUES \(\$1, \$2, \$3\) RETURNING id`)
+	return m.ExpectQuery(`INSERT INTO articles \(title, body, date, format, body_html, slug, status, publish_at\)
+			      VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id, version, created_at, updated_at`)
 }
 
 func createArticle(m sqlmock.Sqlmock, row models.Article) *sqlmock.ExpectedQuery {
-	return expectCreateArticle(m).WithArgs(row.Title, row.Body, row.Date).WillReturnRows(asMockIDRows([]int64{row.ID}))
+	rows := sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(row.ID, row.Version, row.CreatedAt, row.UpdatedAt)
+	return expectCreateArticle(m).WithArgs(row.Title, row.Body, row.Date, row.Format, row.BodyHTML, row.Slug, row.Status, nil).WillReturnRows(rows)
 }
 
-func asMockIDRows(ids []int64) *sqlmock.Rows {
-	rows := sqlmock.NewRows([]string{"id"})
-	for _, i := range ids {
-		rows.AddRow(i)
+func expectCreateArticles(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`INSERT INTO articles \(title, body, date, format, body_html, slug, status, publish_at\)
+			      VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\),\(\$9, \$10, \$11, \$12, \$13, \$14, \$15, \$16\) RETURNING id, version, created_at, updated_at`)
+}
+
+func expectUpdateArticle(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`UPDATE articles SET title = \$1, body = \$2, date = \$3, format = \$4, body_html = \$5, slug = \$6,
+			      status = \$7, publish_at = \$8 WHERE id = \$9 AND version = \$10 RETURNING version, created_at, updated_at`)
+}
+
+// assignSlug expects the slug lookup for base, answered with rows of slug,
+// article_id and whether the slug is the article's current one.
+func assignSlug(m sqlmock.Sqlmock, base string, rows ...[]driver.Value) *sqlmock.ExpectedQuery {
+	slugs := sqlmock.NewRows([]string{"slug", "article_id", "current"})
+	for _, row := range rows {
+		slugs.AddRow(row...)
 	}
-	return rows
+
+	return m.ExpectQuery(`SELECT article_slugs.slug, article_slugs.article_id, article_slugs.slug = articles.slug
+			      FROM article_slugs JOIN articles ON articles.id = article_slugs.article_id
+			      WHERE article_slugs.slug = \$1 OR article_slugs.slug LIKE \$2`).WithArgs(base, base+"-%").WillReturnRows(slugs)
+}
+
+func expectResolveAliases(m sqlmock.Sqlmock, rows ...[]driver.Value) *sqlmock.ExpectedQuery {
+	aliases := sqlmock.NewRows([]string{"alias", "name"})
+	for _, row := range rows {
+		aliases.AddRow(row...)
+	}
+
+	return m.ExpectQuery(`SELECT tag_aliases.alias, tags.name FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id
+			      WHERE tag_aliases.alias = ANY\(\$1\)`).WillReturnRows(aliases)
+}
+
+func expectCanonicalTag(m sqlmock.Sqlmock, name string) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT tags.name FROM tag_aliases JOIN tags ON tags.id = tag_aliases.tag_id
+			      WHERE tag_aliases.alias = \$1`).WithArgs(name)
+}
+
+func expectRecordSlugs(m sqlmock.Sqlmock, values string) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`INSERT INTO article_slugs \(slug, article_id\) VALUES ` + values + ` ON CONFLICT \(slug\) DO NOTHING`)
+}
+
+func expectRecordRevisions(m sqlmock.Sqlmock, count int) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`INSERT INTO article_revisions \(article_id, revision, title, body, date, format, tags, editor\) VALUES ` +
+		strings.TrimSuffix(strings.Repeat(`\(\$\d+, \(SELECT COALESCE\(MAX\(revision\), 0\) \+ 1 FROM article_revisions WHERE article_id = \$\d+\), \$\d+, \$\d+, \$\d+, \$\d+, \$\d+, \$\d+\),`, count), ",")).
+		WillReturnResult(sqlmock.NewResult(0, int64(count)))
+}
+
+func expectArticleStatus(m sqlmock.Sqlmock, article models.Article) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT status, publish_at FROM articles WHERE id = \$1 AND version = \$2 AND deleted_at IS NULL FOR UPDATE`).WithArgs(article.ID, article.Version)
+}
+
+func expectDeleteArticle(m sqlmock.Sqlmock) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`UPDATE articles SET deleted_at = now\(\) WHERE id = \$1 AND version = \$2 AND deleted_at IS NULL`).WithArgs("123", 2)
+}
+
+func expectArticleExists(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM articles WHERE id = \$1 AND deleted_at IS NULL\)`)
 }
 
 func expectCreateTags(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
-	return m.ExpectQuery(`INSERT INTO tags \(name\) VALUES \(\$1\),\(\$2\) ON CONFLICT \(name\) DO UPDATE SET name = EXCLUDED.name RETURNING id`)
+	return m.ExpectQuery(`INSERT INTO tags \(name\) VALUES \(\$1\),\(\$2\) ON CONFLICT \(name\) DO UPDATE SET name = EXCLUDED.name RETURNING id, name`)
 }
 
 func createTags(m sqlmock.Sqlmock, row models.Article) *sqlmock.ExpectedQuery {
-	return expectCreateTags(m).WithArgs(row.Tags[0], row.Tags[1]).WillReturnRows(asMockIDRows([]int64{1, 2}))
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(2, string(row.Tags[1])).AddRow(1, string(row.Tags[0]))
+	return expectCreateTags(m).WithArgs(row.Tags[0], row.Tags[1]).WillReturnRows(rows)
 }
 
 func expectCreateArticleTagMap(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
-	return m.ExpectQuery(`INSERT INTO tags_articles \(article_id, tag_id\) VALUES \(123, 1\),\(123, 2\)`)
+	return m.ExpectQuery(`INSERT INTO tags_articles \(article_id, tag_id, position\) VALUES \(123, 1, 0\),\(123, 2, 1\)`)
 }
 
 func createArticleTagMap(m sqlmock.Sqlmock, row models.Article) *sqlmock.ExpectedQuery {
@@ -264,7 +710,7 @@ func expectLatestArticleswithTag(m sqlmock.Sqlmock, rows *sqlmock.Rows) *sqlmock
 	return m.ExpectQuery(`SELECT array_agg\(article_ids.id::text\) FROM
 				\(SELECT articles.id AS id FROM tags, articles, tags_articles
 				 WHERE tags.id = tags_articles.tag_id AND articles.id = tags_articles.article_id
-			     	 AND tags.name = \$1 AND articles.date = \$2 ORDER BY articles.created_at DESC LIMIT 10\)
+			     	 AND tags.name = \$1 AND articles.date = \$2 AND articles.status = 'published' AND articles.deleted_at IS NULL ORDER BY articles.created_at DESC LIMIT 10\)
 			      	 AS article_ids`).WillReturnRows(rows)
 }
 
@@ -272,7 +718,19 @@ func expectArticlesCount(m sqlmock.Sqlmock, rows *sqlmock.Rows) *sqlmock.Expecte
 	return m.ExpectQuery(`SELECT COUNT\(articles.id\)
 				 FROM tags, articles, tags_articles
 				 WHERE tags.id = tags_articles.tag_id AND articles.id = tags_articles.article_id
-			     	 AND tags.name = \$1 AND articles.date = \$2`).WillReturnRows(rows)
+			     	 AND tags.name = \$1 AND articles.date = \$2 AND articles.status = 'published' AND articles.deleted_at IS NULL`).WillReturnRows(rows)
+}
+
+// descendantArticles matches the published articles tagged with any tag of
+// the tag_tree that expectTagTree defines.
+const descendantArticles = `FROM articles WHERE articles.id IN \(SELECT tags_articles.article_id FROM tags_articles, tag_tree
+			   WHERE tags_articles.tag_id = tag_tree.id\) AND articles.date = \$2
+			   AND articles.status = 'published' AND articles.deleted_at IS NULL`
+
+func expectTagTree(m sqlmock.Sqlmock, query string) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`WITH RECURSIVE tag_tree AS \(SELECT id FROM tags WHERE name = \$1
+			      UNION SELECT tags.id FROM tags JOIN tag_tree ON tags.parent_id = tag_tree.id\) `+query).
+		WithArgs("sports", "20180101").WillReturnRows(emptyRows())
 }
 
 func expectRelatedTags(m sqlmock.Sqlmock, rows *sqlmock.Rows) *sqlmock.ExpectedQuery {
@@ -281,7 +739,7 @@ func expectRelatedTags(m sqlmock.Sqlmock, rows *sqlmock.Rows) *sqlmock.ExpectedQ
 			      AND tags_articles.article_id IN \(SELECT articles.id
 				 FROM tags, articles, tags_articles
 				 WHERE tags.id = tags_articles.tag_id AND articles.id = tags_articles.article_id
-			     	 AND tags.name = \$1 AND articles.date = \$2\)`).WillReturnRows(rows)
+			     	 AND tags.name = \$1 AND articles.date = \$2 AND articles.status = 'published' AND articles.deleted_at IS NULL\)`).WillReturnRows(rows)
 }
 
 func mockedRows(fields []string, values []interface{}) *sqlmock.Rows {
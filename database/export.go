@@ -0,0 +1,84 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+const exportFetchSize = 1000
+
+// ExportArticles reads through a server-side cursor so memory use does not grow
+// with the corpus.
+func (db *DBProvider) ExportArticles(filter models.ExportFilter, fn func(*models.Article) error) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	tag := strings.ToLower(filter.Tag)
+	if tag != "" {
+		if tag, err = canonicalTag(tx, tag); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DECLARE export_articles NO SCROLL CURSOR FOR
+			  SELECT articles.id, articles.title, articles.body, articles.date, articles.version,
+			  articles.created_at, articles.updated_at, articles.format, articles.slug, articles.status, articles.publish_at,
+			  array_remove(array_agg(tags.name ORDER BY tags_articles.position), NULL)
+			  FROM articles LEFT JOIN tags_articles ON articles.id = tags_articles.article_id
+			  LEFT JOIN tags ON tags.id = tags_articles.tag_id
+			  WHERE articles.deleted_at IS NULL AND ($1 = '' OR articles.date >= $1) AND ($2 = '' OR articles.date <= $2)
+			  AND ($3 = '' OR articles.id IN (SELECT tags_articles.article_id FROM tags, tags_articles
+			  WHERE tags.id = tags_articles.tag_id AND tags.name = $3))
+			  GROUP BY articles.id ORDER BY articles.id`,
+		filter.From, filter.To, tag)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to open export cursor")
+	}
+
+	for {
+		rows, err := tx.Queryx(fmt.Sprintf("FETCH FORWARD %d FROM export_articles", exportFetchSize))
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch articles")
+		}
+
+		fetched := 0
+		for rows.Next() {
+			article := &models.Article{}
+			var tags pq.StringArray
+
+			err = rows.Scan(&article.ID, &article.Title, &article.Body, &article.Date, &article.Version,
+				&article.CreatedAt, &article.UpdatedAt, &article.Format, &article.Slug, &article.Status, &article.PublishAt, &tags)
+			if err == nil {
+				article.Tags = stringArrayToTags(tags)
+				err = fn(article)
+			}
+
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			fetched++
+		}
+
+		if err = rows.Err(); err != nil {
+			return errors.Wrap(err, "failed to fetch articles")
+		}
+
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to close export cursor")
+}
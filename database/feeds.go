@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func (db *DBProvider) LatestArticles(tag string, limit int) ([]*models.Article, error) {
+	tag = strings.ToLower(tag)
+	if tag != "" {
+		var err error
+		if tag, err = canonicalTag(db.Connection, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	statement := fmt.Sprintf(`SELECT articles.id, articles.title, articles.body, articles.date, articles.version,
+				  articles.created_at, articles.updated_at, articles.format, articles.body_html, articles.slug, articles.status, articles.publish_at,
+				  array_remove(array_agg(tags.name ORDER BY tags_articles.position), NULL)
+				  FROM articles LEFT JOIN tags_articles ON articles.id = tags_articles.article_id
+				  LEFT JOIN tags ON tags.id = tags_articles.tag_id
+				  WHERE ($1 = '' OR articles.id IN (SELECT tags_articles.article_id FROM tags, tags_articles
+				  WHERE tags.id = tags_articles.tag_id AND tags.name = $1)) AND %s
+				  GROUP BY articles.id %s LIMIT $2`, publishedOnly, latestFirst)
+
+	rows, err := db.Connection.Queryx(statement, tag, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve latest articles")
+	}
+
+	defer rows.Close()
+
+	var articles []*models.Article
+	for rows.Next() {
+		article := &models.Article{}
+		var tags pq.StringArray
+
+		err = rows.Scan(&article.ID, &article.Title, &article.Body, &article.Date, &article.Version,
+			&article.CreatedAt, &article.UpdatedAt, &article.Format, &article.BodyHTML, &article.Slug, &article.Status, &article.PublishAt, &tags)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read latest articles")
+		}
+
+		article.Tags = stringArrayToTags(tags)
+		articles = append(articles, article)
+	}
+
+	return articles, errors.Wrap(rows.Err(), "failed to read latest articles")
+}
@@ -0,0 +1,85 @@
+package database_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestLatestArticles(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		VerifyArticles func(t *testing.T, articles []*models.Article)
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Database error",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
+				expectLatestArticles(m).WithArgs("sports", 20).WillReturnError(errors.New("database error"))
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to retrieve latest articles: database error", "Error")
+			},
+		},
+		{
+			Name: "Latest articles of a tag",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "title", "body", "date", "version", "created_at", "updated_at", "format", "body_html", "slug", "status", "publish_at", "tags"}).
+					AddRow(2, "z2", "*z3*", "2018-06-12", 1, createdAt, createdAt, "markdown", "<p><em>z3</em></p>", "z2", "published", nil, "{sports,music}").
+					AddRow(1, "z1", "z3", "2018-06-11", 3, createdAt, createdAt, "plain", "<p>z3</p>", "z1", "published", nil, "{sports}")
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
+				expectLatestArticles(m).WithArgs("sports", 20).WillReturnRows(rows)
+			},
+			VerifyArticles: func(t *testing.T, articles []*models.Article) {
+				require.Len(t, articles, 2, "articles")
+				assert.Equal(t, int64(2), articles[0].ID, "latest article first")
+				assert.Equal(t, []models.Tag{"sports", "music"}, articles[0].Tags, "tags")
+				assert.Equal(t, "<p><em>z3</em></p>", articles[0].BodyHTML, "rendered body")
+				assert.Equal(t, "z2", articles[0].Slug, "slug")
+				assert.Equal(t, int64(3), articles[1].Version, "version")
+			},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			articles, err := provider.LatestArticles("Sports", 20)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			d.VerifyArticles(t, articles)
+		})
+	}
+}
+
+func expectLatestArticles(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT articles.id, .+ FROM articles LEFT JOIN tags_articles .+
+				WHERE \(\$1 = '' OR articles.id IN \(.+ AND tags.name = \$1\)\) AND articles.status = 'published' AND articles.deleted_at IS NULL
+				GROUP BY articles.id ORDER BY articles.created_at DESC LIMIT \$2`)
+}
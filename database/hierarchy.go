@@ -0,0 +1,136 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+// UNION rather than UNION ALL stops at tags already visited.
+const tagTreeStatement = `WITH RECURSIVE tag_tree AS (SELECT id FROM tags WHERE name = $1
+			  UNION SELECT tags.id FROM tags JOIN tag_tree ON tags.parent_id = tag_tree.id)`
+
+const tagAncestorsStatement = `WITH RECURSIVE ancestors AS (SELECT id, parent_id FROM tags WHERE id = $1
+			       UNION SELECT tags.id, tags.parent_id FROM tags JOIN ancestors ON tags.id = ancestors.parent_id)`
+
+// tagHierarchyLock keeps concurrent moves from closing a cycle between them.
+const tagHierarchyLock = "tag_hierarchy"
+
+func (db *DBProvider) TagTree() ([]models.TagNode, error) {
+	rows, err := db.Connection.Queryx(`SELECT id, name, parent_id FROM tags
+					   WHERE parent_id IS NOT NULL OR id IN (SELECT parent_id FROM tags)
+					   ORDER BY name`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve tag tree")
+	}
+
+	defer rows.Close()
+
+	names := make(map[int64]models.Tag)
+	children := make(map[int64][]int64)
+	roots := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+		var name models.Tag
+		var parent sql.NullInt64
+
+		if err = rows.Scan(&id, &name, &parent); err != nil {
+			return nil, errors.Wrap(err, "failed to read tag tree")
+		}
+
+		names[id] = name
+		if parent.Valid {
+			children[parent.Int64] = append(children[parent.Int64], id)
+		} else {
+			roots = append(roots, id)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read tag tree")
+	}
+
+	var nest func(ids []int64) []models.TagNode
+	nest = func(ids []int64) []models.TagNode {
+		nodes := make([]models.TagNode, 0, len(ids))
+		for _, id := range ids {
+			nodes = append(nodes, models.TagNode{Children: nest(children[id]), Name: names[id]})
+		}
+
+		return nodes
+	}
+
+	return nest(roots), nil
+}
+
+// SetTagParent expects both names to have been through the tag pipeline; they
+// may be aliases.
+func (db *DBProvider) SetTagParent(tag string, parent string) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if err = lockTagHierarchy(tx); err != nil {
+		return err
+	}
+
+	names := []string{tag}
+	if parent != "" {
+		names = append(names, parent)
+	}
+
+	for idx := range names {
+		if names[idx], err = canonicalTag(tx, names[idx]); err != nil {
+			return err
+		}
+	}
+
+	if len(names) > 1 && names[0] == names[1] {
+		return providers.ErrConflict
+	}
+
+	ids, err := lockTags(tx, names...)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) < len(names) {
+		return providers.ErrNotFound
+	}
+
+	var parentID *int64
+	if parent != "" {
+		id := ids[names[1]]
+		parentID = &id
+
+		var cycle bool
+		err = tx.QueryRowx(tagAncestorsStatement+` SELECT EXISTS(SELECT 1 FROM ancestors WHERE id = $2)`, id, ids[names[0]]).
+			Scan(&cycle)
+		if err != nil {
+			return errors.Wrap(err, "failed to check tag ancestors")
+		}
+
+		if cycle {
+			return providers.ErrConflict
+		}
+	}
+
+	if _, err = tx.Exec(`UPDATE tags SET parent_id = $2 WHERE id = $1`, ids[names[0]], parentID); err != nil {
+		return errors.Wrap(err, "failed to set tag parent")
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit tag parent")
+}
+
+func lockTagHierarchy(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, tagHierarchyLock)
+	return errors.Wrap(err, "failed to lock tag hierarchy")
+}
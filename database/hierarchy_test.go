@@ -0,0 +1,157 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func TestTagTree(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id, name, parent_id FROM tags
+			  WHERE parent_id IS NOT NULL OR id IN \(SELECT parent_id FROM tags\) ORDER BY name`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).
+			AddRow(2, "football", 1).
+			AddRow(4, "music", nil).
+			AddRow(3, "premier-league", 2).
+			AddRow(5, "rock", 4).
+			AddRow(6, "rugby", 1).
+			AddRow(1, "sports", nil))
+
+	provider := database.DBProvider{&config.Config{}, db}
+	tree, err := provider.TagTree()
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	assert.NoError(t, err, "Error")
+	assert.Equal(t, []models.TagNode{
+		{Name: "music", Children: []models.TagNode{
+			{Name: "rock", Children: []models.TagNode{}},
+		}},
+		{Name: "sports", Children: []models.TagNode{
+			{Name: "football", Children: []models.TagNode{
+				{Name: "premier-league", Children: []models.TagNode{}},
+			}},
+			{Name: "rugby", Children: []models.TagNode{}},
+		}},
+	}, tree, "tree")
+}
+
+func TestSetTagParent(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		Parent         string
+		MockOperations func(m sqlmock.Sqlmock)
+		ExpectedError  error
+	}{
+		{
+			Name:   "Failure - parent not exist",
+			Parent: "sports",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectCanonicalTag(m, "football").WillReturnError(sql.ErrNoRows)
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				expectLockTag(m, "sports").WillReturnError(sql.ErrNoRows)
+				m.ExpectRollback()
+			},
+			ExpectedError: providers.ErrNotFound,
+		},
+		{
+			Name:   "Failure - parent is an alias of the tag",
+			Parent: "soccer",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectCanonicalTag(m, "football").WillReturnError(sql.ErrNoRows)
+				expectCanonicalTag(m, "soccer").WillReturnRows(mockedRows([]string{"name"}, []interface{}{"football"}))
+				m.ExpectRollback()
+			},
+			ExpectedError: providers.ErrConflict,
+		},
+		{
+			Name:   "Failure - parent nested under the tag",
+			Parent: "premier-league",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectCanonicalTag(m, "football").WillReturnError(sql.ErrNoRows)
+				expectCanonicalTag(m, "premier-league").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				expectLockTag(m, "premier-league").WillReturnRows(mockedRows([]string{"id"}, []interface{}{3}))
+				expectTagAncestor(m, 3, 2).WillReturnRows(mockedRows([]string{"exists"}, []interface{}{true}))
+				m.ExpectRollback()
+			},
+			ExpectedError: providers.ErrConflict,
+		},
+		{
+			Name:   "Success - tag nested",
+			Parent: "sports",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectCanonicalTag(m, "football").WillReturnError(sql.ErrNoRows)
+				expectCanonicalTag(m, "sports").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				expectLockTag(m, "sports").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+				expectTagAncestor(m, 1, 2).WillReturnRows(mockedRows([]string{"exists"}, []interface{}{false}))
+				m.ExpectExec(`UPDATE tags SET parent_id = \$2 WHERE id = \$1`).WithArgs(2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+		},
+		{
+			Name: "Success - tag made a root",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectCanonicalTag(m, "football").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				m.ExpectExec(`UPDATE tags SET parent_id = \$2 WHERE id = \$1`).WithArgs(2, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.SetTagParent("football", d.Parent)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			assert.Equal(t, d.ExpectedError, err, "Error")
+		})
+	}
+}
+
+func expectLockTagHierarchy(m sqlmock.Sqlmock) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\)`).WithArgs("tag_hierarchy").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectTagAncestor(m sqlmock.Sqlmock, id int64, ancestor int64) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`WITH RECURSIVE ancestors AS \(SELECT id, parent_id FROM tags WHERE id = \$1
+			      UNION SELECT tags.id, tags.parent_id FROM tags JOIN ancestors ON tags.id = ancestors.parent_id\)
+			      SELECT EXISTS\(SELECT 1 FROM ancestors WHERE id = \$2\)`).WithArgs(id, ancestor)
+}
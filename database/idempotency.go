@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+// ReserveIdempotencyKey returns false when the key is still held.
+func (db *DBProvider) ReserveIdempotencyKey(key *models.IdempotencyKey, expiredBefore time.Time) (bool, error) {
+	err := db.Connection.QueryRowx(
+		`INSERT INTO idempotency_keys (key, request_hash) VALUES ($1, $2)
+		 ON CONFLICT (key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = 0, response = NULL, response_headers = NULL,
+		 created_at = CURRENT_TIMESTAMP WHERE idempotency_keys.created_at < $3
+		 RETURNING created_at`,
+		key.Key,
+		key.RequestHash,
+		expiredBefore,
+	).Scan(&key.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errors.Wrap(err, "failed to reserve idempotency key")
+	}
+
+	return true, nil
+}
+
+func (db *DBProvider) FindIdempotencyKey(key string) (*models.IdempotencyKey, error) {
+	stored := &models.IdempotencyKey{}
+	var headers []byte
+	err := db.Connection.QueryRowx(
+		`SELECT key, request_hash, status, response, response_headers, created_at FROM idempotency_keys WHERE key = $1`,
+		key,
+	).Scan(&stored.Key, &stored.RequestHash, &stored.Status, &stored.Response, &headers, &stored.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to retrieve idempotency key")
+	}
+
+	if headers != nil {
+		if err = json.Unmarshal(headers, &stored.Headers); err != nil {
+			return nil, errors.Wrap(err, "failed to read idempotency key headers")
+		}
+	}
+
+	return stored, nil
+}
+
+func (db *DBProvider) SaveIdempotencyKey(key *models.IdempotencyKey) error {
+	headers, err := json.Marshal(key.Headers)
+	if err != nil {
+		return errors.Wrap(err, "failed to save idempotency key")
+	}
+
+	_, err = db.Connection.Exec(
+		`UPDATE idempotency_keys SET status = $1, response = $2, response_headers = $3 WHERE key = $4`,
+		key.Status,
+		key.Response,
+		headers,
+		key.Key,
+	)
+
+	return errors.Wrap(err, "failed to save idempotency key")
+}
+
+func (db *DBProvider) DeleteIdempotencyKey(key string) error {
+	_, err := db.Connection.Exec(`DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return errors.Wrap(err, "failed to delete idempotency key")
+}
+
+func (db *DBProvider) PurgeIdempotencyKeys(before time.Time) (int64, error) {
+	result, err := db.Connection.Exec(`DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to purge idempotency keys")
+	}
+
+	return result.RowsAffected()
+}
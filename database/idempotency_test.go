@@ -0,0 +1,64 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	expiredBefore := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		Reserved       bool
+	}{
+		{
+			Name: "Success - key reserved",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectReserveIdempotencyKey(m).WithArgs("abc", "hash", expiredBefore).
+					WillReturnRows(mockedRows([]string{"created_at"}, []interface{}{expiredBefore}))
+			},
+			Reserved: true,
+		},
+		{
+			Name: "Success - key already held",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectReserveIdempotencyKey(m).WithArgs("abc", "hash", expiredBefore).WillReturnError(sql.ErrNoRows)
+			},
+		},
+	}
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			reserved, err := provider.ReserveIdempotencyKey(&models.IdempotencyKey{Key: "abc", RequestHash: "hash"}, expiredBefore)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.Reserved, reserved, "%s: reserved", d.Name)
+		})
+	}
+}
+
+func expectReserveIdempotencyKey(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`INSERT INTO idempotency_keys \(key, request_hash\) VALUES \(\$1, \$2\)
+			      ON CONFLICT \(key\) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = 0, response = NULL, response_headers = NULL,
+			      created_at = CURRENT_TIMESTAMP WHERE idempotency_keys.created_at < \$3
+			      RETURNING created_at`)
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+const revisionColumns = `article_id, revision, title, body, date, format, tags, editor, created_at`
+
+const notDeleted = `article_id IN (SELECT id FROM articles WHERE deleted_at IS NULL)`
+
+func (db *DBProvider) ArticleRevisions(id string) ([]models.Revision, error) {
+	rows, err := db.Connection.Queryx(`SELECT `+revisionColumns+` FROM article_revisions
+					   WHERE article_id = $1 AND `+notDeleted+` ORDER BY revision`, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve article revisions")
+	}
+
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0)
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read article revisions")
+		}
+
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, errors.Wrap(rows.Err(), "failed to read article revisions")
+}
+
+func (db *DBProvider) ArticleRevision(id string, number int) (*models.Revision, error) {
+	row := db.Connection.QueryRowx(`SELECT `+revisionColumns+` FROM article_revisions
+					WHERE article_id = $1 AND revision = $2 AND `+notDeleted, id, number)
+
+	revision, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return revision, errors.Wrap(err, "failed to retrieve article revision")
+}
+
+func scanRevision(row sqlx.ColScanner) (*models.Revision, error) {
+	revision := &models.Revision{}
+	var tags pq.StringArray
+
+	err := row.Scan(&revision.ArticleID, &revision.Number, &revision.Title, &revision.Body, &revision.Date,
+		&revision.Format, &tags, &revision.Editor, &revision.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.Tags = stringArrayToTags(tags)
+	return revision, nil
+}
+
+func recordRevisions(q sqlx.Execer, articles ...*models.Article) error {
+	valueIndexes := make([]string, 0, len(articles))
+	values := make([]interface{}, 0, 7*len(articles))
+
+	for idx, article := range articles {
+		n := 7 * idx
+		valueIndexes = append(valueIndexes, fmt.Sprintf(
+			"($%d, (SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revisions WHERE article_id = $%d), $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+
+		tags := make(pq.StringArray, 0, len(article.Tags))
+		for _, tag := range article.Tags {
+			tags = append(tags, string(tag))
+		}
+
+		values = append(values, article.ID, article.Title, article.Body, article.Date, article.Format, tags, article.Editor)
+	}
+
+	statement := fmt.Sprintf("INSERT INTO article_revisions (article_id, revision, title, body, date, format, tags, editor) VALUES %s",
+		strings.Join(valueIndexes, ","))
+	_, err := q.Exec(statement, values...)
+
+	return errors.Wrap(err, "failed to record article revision")
+}
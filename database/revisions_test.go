@@ -0,0 +1,168 @@
+package database_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+)
+
+var revisionFields = []string{"article_id", "revision", "title", "body", "date", "format", "tags", "editor", "created_at"}
+
+func TestArticleRevisions(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		Expected       []models.Revision
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - db error",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleRevisions(m).WillReturnError(errors.New("database error"))
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to retrieve article revisions: database error", "Error")
+			},
+		},
+		{
+			Name: "Success - revisions found",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleRevisions(m).WillReturnRows(sqlmock.NewRows(revisionFields).
+					AddRow(123, 1, "z1", "z3", "2018-06-12", "", "{sports}", "", createdAt).
+					AddRow(123, 2, "z2", "z3", "2018-06-12", "markdown", "{sports,music}", "eve", createdAt))
+			},
+			Expected: []models.Revision{
+				{ArticleID: 123, Body: "z3", CreatedAt: createdAt, Date: "2018-06-12", Number: 1, Tags: []models.Tag{"sports"}, Title: "z1"},
+				{ArticleID: 123, Body: "z3", CreatedAt: createdAt, Date: "2018-06-12", Editor: "eve", Format: "markdown", Number: 2,
+					Tags: []models.Tag{"sports", "music"}, Title: "z2"},
+			},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			revisions, err := provider.ArticleRevisions("123")
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.Expected, revisions, "%s: revisions", d.Name)
+		})
+	}
+}
+
+func TestArticleRevision(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		Expected       *models.Revision
+	}{
+		{
+			Name: "Success - no revision found",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleRevision(m).WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			Name: "Success - revision found",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleRevision(m).WillReturnRows(sqlmock.NewRows(revisionFields).
+					AddRow(123, 2, "z1", "z3", "2018-06-12", "", "{sports}", "eve", createdAt))
+			},
+			Expected: &models.Revision{ArticleID: 123, Body: "z3", CreatedAt: createdAt, Date: "2018-06-12", Editor: "eve", Number: 2,
+				Tags: []models.Tag{"sports"}, Title: "z1"},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			revision, err := provider.ArticleRevision("123", 2)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.Expected, revision, "%s: revision", d.Name)
+		})
+	}
+}
+
+func TestRestoreMergedTag(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	article := models.Article{Body: "z4", Date: "2018-06-13", ID: 123, Slug: "z1", Tags: []models.Tag{"music"}, Title: "z1", Version: 2}
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	mergeTags(mock, "football", "soccer")
+	expectArticleRevision(mock).WillReturnRows(sqlmock.NewRows(revisionFields).
+		AddRow(123, 2, "z1", "z3", "2018-06-12", "", "{football,music}", "eve", createdAt))
+	mock.ExpectBegin()
+	expectArticleStatus(mock, article).WillReturnRows(sqlmock.NewRows([]string{"status", "publish_at"}).AddRow("published", nil))
+	expectResolveAliases(mock, []driver.Value{"football", "soccer"})
+	assignSlug(mock, "z1")
+	expectUpdateArticle(mock).WillReturnRows(sqlmock.NewRows([]string{"version", "created_at", "updated_at"}).
+		AddRow(article.Version+1, createdAt, createdAt))
+	expectRecordSlugs(mock, `\(\$1, \$2\)`).WithArgs("z1", article.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectRecordRevisions(mock, 1)
+	mock.ExpectExec(`DELETE FROM tags_articles WHERE article_id = \$1`).WithArgs(article.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	createTags(mock, models.Article{Tags: []models.Tag{"soccer", "music"}})
+	createArticleTagMap(mock, article)
+	mock.ExpectCommit()
+
+	provider := database.DBProvider{&config.Config{}, db}
+	require.NoError(t, provider.MergeTags("football", "soccer", "eve"), "merge")
+	revision, err := provider.ArticleRevision("123", 2)
+	require.NoError(t, err, "revision")
+	article.Restore(revision)
+	err = provider.UpdateArticle(&article)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	assert.NoError(t, err, "Error")
+	assert.Equal(t, []models.Tag{"soccer", "music"}, article.Tags, "tags")
+}
+
+func expectArticleRevisions(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT article_id, revision, title, body, date, format, tags, editor, created_at FROM article_revisions
+			      WHERE article_id = \$1 AND article_id IN \(SELECT id FROM articles WHERE deleted_at IS NULL\) ORDER BY revision`).WithArgs("123")
+}
+
+func expectArticleRevision(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT article_id, revision, title, body, date, format, tags, editor, created_at FROM article_revisions
+			      WHERE article_id = \$1 AND revision = \$2 AND article_id IN \(SELECT id FROM articles WHERE deleted_at IS NULL\)`).WithArgs("123", 2)
+}
@@ -0,0 +1,18 @@
+package database
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (db *DBProvider) PublishScheduled(now time.Time) (int64, error) {
+	result, err := db.Connection.Exec(`UPDATE articles SET status = 'published'
+					   WHERE status = 'scheduled' AND publish_at <= $1 AND deleted_at IS NULL`, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to publish scheduled articles")
+	}
+
+	published, err := result.RowsAffected()
+	return published, errors.Wrap(err, "failed to publish scheduled articles")
+}
@@ -0,0 +1,70 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+)
+
+func TestPublishScheduled(t *testing.T) {
+	now := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name              string
+		MockOperations    func(m sqlmock.Sqlmock)
+		ExpectedPublished int64
+		VerifyError       func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - db error",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectPublishScheduled(m).WillReturnError(errors.New("database error"))
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to publish scheduled articles: database error", "Error")
+			},
+		},
+		{
+			Name: "Success - due articles published",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectPublishScheduled(m).WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			ExpectedPublished: 2,
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			published, err := provider.PublishScheduled(now)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.ExpectedPublished, published, "%s: published", d.Name)
+		})
+	}
+}
+
+func expectPublishScheduled(m sqlmock.Sqlmock) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`UPDATE articles SET status = 'published' WHERE status = 'scheduled' AND publish_at <= \$1 AND deleted_at IS NULL`).
+		WithArgs(time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func (db *DBProvider) SitemapPages(pageSize int) ([]models.SitemapPage, error) {
+	rows, err := db.Connection.Queryx(`SELECT numbered.page + 1, MAX(numbered.updated_at)
+					   FROM (SELECT (row_number() OVER (ORDER BY articles.id) - 1) / $1 AS page,
+					   articles.updated_at FROM articles WHERE `+publishedOnly+`) AS numbered
+					   GROUP BY numbered.page ORDER BY numbered.page`, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve sitemap pages")
+	}
+
+	defer rows.Close()
+
+	var pages []models.SitemapPage
+	for rows.Next() {
+		page := models.SitemapPage{}
+		if err = rows.Scan(&page.Page, &page.LastModified); err != nil {
+			return nil, errors.Wrap(err, "failed to read sitemap pages")
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages, errors.Wrap(rows.Err(), "failed to read sitemap pages")
+}
+
+func (db *DBProvider) SitemapEntries(page int, pageSize int, fn func(models.SitemapEntry) error) error {
+	rows, err := db.Connection.Queryx(`SELECT articles.id, articles.slug, articles.updated_at FROM articles WHERE `+publishedOnly+`
+					   ORDER BY articles.id LIMIT $1 OFFSET $2`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve sitemap entries")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := models.SitemapEntry{}
+		if err = rows.Scan(&entry.ID, &entry.Slug, &entry.UpdatedAt); err != nil {
+			return errors.Wrap(err, "failed to read sitemap entries")
+		}
+
+		if err = fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(rows.Err(), "failed to read sitemap entries")
+}
@@ -0,0 +1,85 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestSitemapPages(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	modified := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT numbered.page \+ 1, MAX\(numbered.updated_at\) FROM \(SELECT \(row_number\(\) OVER \(ORDER BY articles.id\) - 1\) / \$1 AS page, .+ WHERE articles.status = 'published' AND articles.deleted_at IS NULL\) AS numbered GROUP BY numbered.page ORDER BY numbered.page`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"page", "max"}).AddRow(1, modified).AddRow(2, modified))
+	provider := database.DBProvider{&config.Config{}, db}
+
+	pages, err := provider.SitemapPages(2)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	require.NoError(t, err, "Error")
+	assert.Equal(t, []models.SitemapPage{{LastModified: modified, Page: 1}, {LastModified: modified, Page: 2}}, pages, "pages")
+}
+
+func TestSitemapEntries(t *testing.T) {
+	modified := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	stop := errors.New("stop")
+
+	testTable := []struct {
+		Name          string
+		Stop          bool
+		ExpectedIDs   []int64
+		ExpectedError error
+	}{
+		{
+			Name:        "Every entry of the page",
+			ExpectedIDs: []int64{3, 4},
+		},
+		{
+			Name:          "Callback error stops the stream",
+			Stop:          true,
+			ExpectedIDs:   []int64{3},
+			ExpectedError: stop,
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			mock.ExpectQuery(`SELECT articles.id, articles.slug, articles.updated_at FROM articles WHERE articles.status = 'published' AND articles.deleted_at IS NULL ORDER BY articles.id LIMIT \$1 OFFSET \$2`).
+				WithArgs(2, 2).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "updated_at"}).AddRow(3, "z3", modified).AddRow(4, "z4", modified))
+			provider := database.DBProvider{&config.Config{}, db}
+
+			var ids []int64
+			err = provider.SitemapEntries(2, 2, func(entry models.SitemapEntry) error {
+				ids = append(ids, entry.ID)
+				if d.Stop {
+					return stop
+				}
+				return nil
+			})
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			assert.Equal(t, d.ExpectedError, err, "Error")
+			assert.Equal(t, d.ExpectedIDs, ids, "ids")
+		})
+	}
+}
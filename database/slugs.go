@@ -0,0 +1,137 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+// FindArticleBySlug also resolves former slugs; callers compare slugs to redirect.
+func (db *DBProvider) FindArticleBySlug(slug string) (*models.Article, error) {
+	var id int64
+	err := db.Connection.QueryRowx(`SELECT article_id FROM article_slugs WHERE slug = $1`, slug).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve article slug")
+	}
+
+	return db.FindArticle(strconv.FormatInt(id, 10))
+}
+
+// assignSlug picks the first of base, base-2, base-3... no other article has
+// ever used. taken holds the slugs claimed earlier in the same batch.
+func assignSlug(tx *sqlx.Tx, article *models.Article, taken map[string]bool) error {
+	base := article.Slug
+
+	rows, err := tx.Queryx(`SELECT article_slugs.slug, article_slugs.article_id, article_slugs.slug = articles.slug
+				FROM article_slugs JOIN articles ON articles.id = article_slugs.article_id
+				WHERE article_slugs.slug = $1 OR article_slugs.slug LIKE $2`, base, base+"-%")
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve article slugs")
+	}
+
+	defer rows.Close()
+
+	owners := make(map[string]int64)
+	for rows.Next() {
+		var slug string
+		var owner int64
+		var current bool
+
+		if err = rows.Scan(&slug, &owner, &current); err != nil {
+			return errors.Wrap(err, "failed to retrieve article slugs")
+		}
+
+		if current && owner == article.ID && derivesFrom(slug, base) {
+			article.Slug = slug
+			return nil
+		}
+
+		owners[slug] = owner
+	}
+
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to retrieve article slugs")
+	}
+
+	for n := 1; ; n++ {
+		slug := base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		if owner, ok := owners[slug]; (!ok || owner == article.ID) && !taken[slug] {
+			article.Slug = slug
+			if taken != nil {
+				taken[slug] = true
+			}
+
+			return nil
+		}
+	}
+}
+
+const slugAttempts = 3
+
+// retrySlugConflicts reruns write on the articles as they were when a
+// concurrent write claimed the slug it picked.
+func retrySlugConflicts(write func() error, articles ...*models.Article) error {
+	saved := make([]models.Article, len(articles))
+	for idx, article := range articles {
+		saved[idx] = *article
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := write()
+
+		pqErr, ok := errors.Cause(err).(*pq.Error)
+		if !ok || pqErr.Code != "23505" || pqErr.Constraint != "articles_slug_key" || attempt == slugAttempts {
+			return err
+		}
+
+		for idx, article := range articles {
+			*article = saved[idx]
+		}
+	}
+}
+
+func derivesFrom(slug string, base string) bool {
+	if slug == base {
+		return true
+	}
+
+	suffix := strings.TrimPrefix(slug, base+"-")
+	if suffix == slug || suffix == "" {
+		return false
+	}
+
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+func recordSlugs(q sqlx.Execer, articles ...*models.Article) error {
+	valueIndexes := make([]string, 0, len(articles))
+	values := make([]interface{}, 0, 2*len(articles))
+
+	for idx, article := range articles {
+		valueIndexes = append(valueIndexes, fmt.Sprintf("($%d, $%d)", 2*idx+1, 2*idx+2))
+		values = append(values, article.Slug, article.ID)
+	}
+
+	statement := fmt.Sprintf("INSERT INTO article_slugs (slug, article_id) VALUES %s ON CONFLICT (slug) DO NOTHING",
+		strings.Join(valueIndexes, ","))
+	_, err := q.Exec(statement, values...)
+
+	return errors.Wrap(err, "failed to record article slugs")
+}
@@ -0,0 +1,77 @@
+package database_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestFindArticleBySlug(t *testing.T) {
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Slug: "z1-2", Tags: []models.Tag{"sports"}, Title: "z1", Version: 1}
+
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		Expected       *models.Article
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - db error",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleSlug(m).WillReturnError(errors.New("database error"))
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to retrieve article slug: database error", "Error")
+			},
+		},
+		{
+			Name: "Success - unknown slug",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleSlug(m).WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			Name: "Success - former slug resolves to the article",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectArticleSlug(m).WillReturnRows(mockedRows([]string{"article_id"}, []interface{}{123}))
+				selectArticleWithID(m, "123", article)
+			},
+			Expected: &article,
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			found, err := provider.FindArticleBySlug("z1")
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.Expected, found, "%s: article", d.Name)
+		})
+	}
+}
+
+func expectArticleSlug(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT article_id FROM article_slugs WHERE slug = \$1`).WithArgs("z1")
+}
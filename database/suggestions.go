@@ -0,0 +1,41 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SuggestTags still suggests the tags since leaves unused, last.
+func (db *DBProvider) SuggestTags(prefix string, since string, limit int) ([]models.TagSuggestion, error) {
+	statement := fmt.Sprintf(`SELECT tags.name, COUNT(articles.id) FROM tags
+				  LEFT JOIN tags_articles ON tags_articles.tag_id = tags.id
+				  LEFT JOIN articles ON articles.id = tags_articles.article_id AND %s
+				  AND ($2 = '' OR articles.date >= $2)
+				  WHERE tags.name LIKE $1
+				  GROUP BY tags.id ORDER BY COUNT(articles.id) DESC, tags.name LIMIT $3`, publishedOnly)
+
+	rows, err := db.Connection.Queryx(statement, likeEscaper.Replace(strings.ToLower(prefix))+"%", since, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to suggest tags")
+	}
+
+	defer rows.Close()
+
+	suggestions := make([]models.TagSuggestion, 0, limit)
+	for rows.Next() {
+		suggestion := models.TagSuggestion{}
+		if err = rows.Scan(&suggestion.Name, &suggestion.Count); err != nil {
+			return nil, errors.Wrap(err, "failed to read tag suggestions")
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, errors.Wrap(rows.Err(), "failed to read tag suggestions")
+}
@@ -0,0 +1,77 @@
+package database_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestSuggestTags(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		Prefix         string
+		Since          string
+		ExpectedArgs   []driver.Value
+		Rows           *sqlmock.Rows
+		ExpectedResult []models.TagSuggestion
+	}{
+		{
+			Name:           "No matching tags",
+			Prefix:         "zz",
+			ExpectedArgs:   []driver.Value{"zz%", "", 10},
+			Rows:           sqlmock.NewRows([]string{"name", "count"}),
+			ExpectedResult: []models.TagSuggestion{},
+		},
+		{
+			Name:         "Most used first",
+			Prefix:       "Foo",
+			Since:        "2018-06-01",
+			ExpectedArgs: []driver.Value{"foo%", "2018-06-01", 10},
+			Rows:         sqlmock.NewRows([]string{"name", "count"}).AddRow("football", 12).AddRow("food", 3).AddRow("foobar", 0),
+			ExpectedResult: []models.TagSuggestion{
+				{Count: 12, Name: "football"},
+				{Count: 3, Name: "food"},
+				{Count: 0, Name: "foobar"},
+			},
+		},
+		{
+			Name:           "Wildcards escaped",
+			Prefix:         `50%_off\`,
+			ExpectedArgs:   []driver.Value{`50\%\_off\\%`, "", 10},
+			Rows:           sqlmock.NewRows([]string{"name", "count"}),
+			ExpectedResult: []models.TagSuggestion{},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			mock.ExpectQuery(`SELECT tags.name, COUNT\(articles.id\) FROM tags
+					  LEFT JOIN tags_articles ON tags_articles.tag_id = tags.id
+					  LEFT JOIN articles ON articles.id = tags_articles.article_id
+					  AND articles.status = 'published' AND articles.deleted_at IS NULL
+					  AND \(\$2 = '' OR articles.date >= \$2\) WHERE tags.name LIKE \$1
+					  GROUP BY tags.id ORDER BY COUNT\(articles.id\) DESC, tags.name LIMIT \$3`).
+				WithArgs(d.ExpectedArgs...).WillReturnRows(d.Rows)
+
+			provider := database.DBProvider{&config.Config{}, db}
+			suggestions, err := provider.SuggestTags(d.Prefix, d.Since, 10)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.ExpectedResult, suggestions, "suggestions")
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+// RenameTag expects both names to have been through the tag pipeline.
+func (db *DBProvider) RenameTag(from string, to string, actor string) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if err = lockTagHierarchy(tx); err != nil {
+		return err
+	}
+
+	ids, err := lockTags(tx, from, to)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ids[from]; !ok {
+		return providers.ErrNotFound
+	}
+
+	if _, ok := ids[to]; ok {
+		return providers.ErrConflict
+	}
+
+	// An alias of that name would keep resolving to its own tag.
+	canonical, err := canonicalTag(tx, to)
+	if err != nil {
+		return err
+	}
+
+	if canonical != to {
+		return providers.ErrConflict
+	}
+
+	if _, err = tx.Exec(`UPDATE tags SET name = $2 WHERE id = $1`, ids[from], to); err != nil {
+		return errors.Wrap(err, "failed to rename tag")
+	}
+
+	if err = touchTaggedArticles(tx, ids[from]); err != nil {
+		return err
+	}
+
+	if err = recordTagChange(tx, models.TagRenamed, from, to, actor); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit tag rename")
+}
+
+// MergeTags expects both names to have been through the tag pipeline; from
+// becomes an alias of into.
+func (db *DBProvider) MergeTags(from string, into string, actor string) error {
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if err = lockTagHierarchy(tx); err != nil {
+		return err
+	}
+
+	ids, err := lockTags(tx, from, into)
+	if err != nil {
+		return err
+	}
+
+	fromID, fromOk := ids[from]
+	intoID, intoOk := ids[into]
+
+	if !fromOk || !intoOk {
+		return providers.ErrNotFound
+	}
+
+	if fromID == intoID {
+		return nil
+	}
+
+	if err = touchTaggedArticles(tx, fromID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE tags_articles SET tag_id = $2 WHERE tag_id = $1
+			  AND article_id NOT IN (SELECT article_id FROM tags_articles WHERE tag_id = $2)`, fromID, intoID)
+	if err != nil {
+		return errors.Wrap(err, "failed to move article tags")
+	}
+
+	if _, err = tx.Exec(`UPDATE tag_aliases SET tag_id = $2 WHERE tag_id = $1`, fromID, intoID); err != nil {
+		return errors.Wrap(err, "failed to move tag aliases")
+	}
+
+	// The tags nested under from move under into. When into is one of them it
+	// first takes from's place, so that it does not end up nested under itself.
+	_, err = tx.Exec(tagAncestorsStatement+` UPDATE tags SET parent_id = (SELECT parent_id FROM tags WHERE id = $2)
+			  WHERE id = $1 AND $2 IN (SELECT id FROM ancestors)`, intoID, fromID)
+	if err != nil {
+		return errors.Wrap(err, "failed to move merged tag")
+	}
+
+	if _, err = tx.Exec(`UPDATE tags SET parent_id = $2 WHERE parent_id = $1`, fromID, intoID); err != nil {
+		return errors.Wrap(err, "failed to move nested tags")
+	}
+
+	if _, err = tx.Exec(`DELETE FROM tags WHERE id = $1`, fromID); err != nil {
+		return errors.Wrap(err, "failed to remove merged tag")
+	}
+
+	if _, err = tx.Exec(`INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)`, from, intoID); err != nil {
+		return errors.Wrap(err, "failed to alias merged tag")
+	}
+
+	if err = recordTagChange(tx, models.TagMerged, from, into, actor); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit tag merge")
+}
+
+// NormalizeTags renames every tag stored before the tag pipeline, or merges it
+// into the tag its normalized name stands for, then validates the name check.
+func (db *DBProvider) NormalizeTags(actor string) (*models.TagNormalizationReport, error) {
+	var names []string
+	if err := db.Connection.Select(&names, `SELECT name FROM tags ORDER BY id`); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve tags")
+	}
+
+	report := &models.TagNormalizationReport{Rejected: make([]string, 0)}
+	for _, name := range names {
+		tag, err := db.Config.Tags.NormalizeName(name)
+		if err != nil || tag == "" {
+			report.Rejected = append(report.Rejected, name)
+			continue
+		}
+
+		if string(tag) == name {
+			continue
+		}
+
+		target, err := canonicalTag(db.Connection, string(tag))
+		if err != nil {
+			return nil, err
+		}
+
+		switch err = db.RenameTag(name, target, actor); errors.Cause(err) {
+		case nil:
+			report.Renamed++
+		case providers.ErrConflict:
+			if err = db.MergeTags(name, target, actor); err != nil {
+				return nil, err
+			}
+
+			report.Merged++
+		case providers.ErrNotFound:
+		default:
+			return nil, err
+		}
+	}
+
+	_, err := db.Connection.Exec(`ALTER TABLE tags VALIDATE CONSTRAINT tags_name_check`)
+	return report, errors.Wrap(err, "failed to validate tag names")
+}
+
+// Reindex keeps unused tags that have nested tags or aliases.
+func (db *DBProvider) Reindex() (*models.ReindexReport, error) {
+	report := &models.ReindexReport{}
+
+	tx, err := db.Connection.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM tags_articles USING tags_articles AS duplicate
+				WHERE tags_articles.article_id = duplicate.article_id
+				AND tags_articles.tag_id = duplicate.tag_id AND tags_articles.id > duplicate.id`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to remove duplicate article tags")
+	}
+
+	if report.DuplicateMappings, err = result.RowsAffected(); err != nil {
+		return nil, errors.Wrap(err, "failed to remove duplicate article tags")
+	}
+
+	result, err = tx.Exec(`DELETE FROM tags WHERE NOT EXISTS
+			       (SELECT 1 FROM tags_articles WHERE tags_articles.tag_id = tags.id)
+			       AND NOT EXISTS (SELECT 1 FROM tags AS children WHERE children.parent_id = tags.id)
+			       AND NOT EXISTS (SELECT 1 FROM tag_aliases WHERE tag_aliases.tag_id = tags.id)`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to remove orphan tags")
+	}
+
+	if report.OrphanTags, err = result.RowsAffected(); err != nil {
+		return nil, errors.Wrap(err, "failed to remove orphan tags")
+	}
+
+	return report, errors.Wrap(tx.Commit(), "failed to commit reindex")
+}
+
+func (db *DBProvider) TagAuditLog(limit int) ([]models.TagChange, error) {
+	rows, err := db.Connection.Queryx(`SELECT id, action, from_tag, to_tag, actor, created_at
+					   FROM tag_audit_log ORDER BY id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve tag audit log")
+	}
+
+	defer rows.Close()
+
+	changes := make([]models.TagChange, 0)
+	for rows.Next() {
+		change := models.TagChange{}
+		if err = rows.Scan(&change.ID, &change.Action, &change.From, &change.To, &change.Actor, &change.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to read tag audit log")
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, errors.Wrap(rows.Err(), "failed to read tag audit log")
+}
+
+func recordTagChange(tx *sqlx.Tx, action string, from string, to string, actor string) error {
+	_, err := tx.Exec(`INSERT INTO tag_audit_log (action, from_tag, to_tag, actor) VALUES ($1, $2, $3, $4)`,
+		action, from, to, actor)
+
+	return errors.Wrap(err, "failed to record tag change")
+}
+
+// touchTaggedArticles bumps the articles whose representations change with the tag name.
+func touchTaggedArticles(tx *sqlx.Tx, tagID int64) error {
+	_, err := tx.Exec(`UPDATE articles SET updated_at = now()
+			   WHERE id IN (SELECT article_id FROM tags_articles WHERE tag_id = $1)`, tagID)
+
+	return errors.Wrap(err, "failed to touch tagged articles")
+}
+
+func lockTags(tx *sqlx.Tx, names ...string) (map[string]int64, error) {
+	ids := make(map[string]int64)
+
+	for _, name := range names {
+		var id int64
+		err := tx.QueryRowx(`SELECT id FROM tags WHERE name = $1 FOR UPDATE`, name).Scan(&id)
+
+		if err == sql.ErrNoRows {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve tag")
+		}
+
+		ids[name] = id
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,277 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func TestRenameTag(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - tag not exist",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectLockTag(m, "football").WillReturnError(sql.ErrNoRows)
+				expectLockTag(m, "soccer").WillReturnError(sql.ErrNoRows)
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrNotFound, err, "Error")
+			},
+		},
+		{
+			Name: "Failure - new name taken",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+				expectLockTag(m, "soccer").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrConflict, err, "Error")
+			},
+		},
+		{
+			Name: "Failure - new name is an alias",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+				expectLockTag(m, "soccer").WillReturnError(sql.ErrNoRows)
+				expectCanonicalTag(m, "soccer").WillReturnRows(mockedRows([]string{"name"}, []interface{}{"football"}))
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrConflict, err, "Error")
+			},
+		},
+		{
+			Name: "Success - rename tag",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+				expectLockTag(m, "soccer").WillReturnError(sql.ErrNoRows)
+				expectCanonicalTag(m, "soccer").WillReturnError(sql.ErrNoRows)
+				m.ExpectExec(`UPDATE tags SET name = \$2 WHERE id = \$1`).WithArgs(1, "soccer").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectTouchTaggedArticles(m, 1)
+				expectRecordTagChange(m, "rename", "football", "soccer")
+				m.ExpectCommit()
+			},
+		},
+	}
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.RenameTag("football", "soccer", "eve")
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - target not exist",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				expectLockTagHierarchy(m)
+				expectLockTag(m, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+				expectLockTag(m, "soccer").WillReturnError(sql.ErrNoRows)
+				m.ExpectRollback()
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.Equal(t, providers.ErrNotFound, err, "Error")
+			},
+		},
+		{
+			Name: "Success - merge tags",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				mergeTags(m, "football", "soccer")
+			},
+		},
+	}
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.MergeTags("football", "soccer", "eve")
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := models.NewTagPipeline([]string{"trim", "casefold", "banned"}, models.TagRules{BannedWords: []string{"spam"}})
+	require.NoError(t, err, "pipeline")
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT name FROM tags ORDER BY id`).
+		WillReturnRows(mockedRows([]string{"name"}, []interface{}{"football", "Football ", "Soccer", "Spam"}))
+	expectCanonicalTag(mock, "football").WillReturnError(sql.ErrNoRows)
+	mock.ExpectBegin()
+	expectLockTagHierarchy(mock)
+	expectLockTag(mock, "Football ").WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+	expectLockTag(mock, "football").WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+	mock.ExpectRollback()
+	mergeTags(mock, "Football ", "football")
+	expectCanonicalTag(mock, "soccer").WillReturnError(sql.ErrNoRows)
+	mock.ExpectBegin()
+	expectLockTagHierarchy(mock)
+	expectLockTag(mock, "Soccer").WillReturnRows(mockedRows([]string{"id"}, []interface{}{3}))
+	expectLockTag(mock, "soccer").WillReturnError(sql.ErrNoRows)
+	expectCanonicalTag(mock, "soccer").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`UPDATE tags SET name = \$2 WHERE id = \$1`).WithArgs(3, "soccer").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTouchTaggedArticles(mock, 3)
+	expectRecordTagChange(mock, "rename", "Soccer", "soccer")
+	mock.ExpectCommit()
+	mock.ExpectExec(`ALTER TABLE tags VALIDATE CONSTRAINT tags_name_check`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	provider := database.DBProvider{&config.Config{Tags: tags}, db}
+	report, err := provider.NormalizeTags("eve")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	assert.NoError(t, err, "Error")
+	assert.Equal(t, &models.TagNormalizationReport{Merged: 1, Rejected: []string{"Spam"}, Renamed: 1}, report, "report")
+}
+
+func TestTagAuditLog(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id, action, from_tag, to_tag, actor, created_at FROM tag_audit_log ORDER BY id DESC LIMIT \$1`).
+		WithArgs(50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "from_tag", "to_tag", "actor", "created_at"}).
+			AddRow(2, "merge", "football", "soccer", "eve", createdAt).
+			AddRow(1, "rename", "football ", "football", "cli:root", createdAt))
+
+	provider := database.DBProvider{&config.Config{}, db}
+	changes, err := provider.TagAuditLog(50)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	assert.NoError(t, err, "Error")
+	assert.Equal(t, []models.TagChange{
+		{Action: models.TagMerged, Actor: "eve", CreatedAt: createdAt, From: "football", ID: 2, To: "soccer"},
+		{Action: models.TagRenamed, Actor: "cli:root", CreatedAt: createdAt, From: "football ", ID: 1, To: "football"},
+	}, changes, "changes")
+}
+
+func TestReindex(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create SqlMock DB")
+	db := sqlx.NewDb(sqlDB, "postgres")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM tags_articles USING tags_articles AS duplicate
+			 WHERE tags_articles.article_id = duplicate.article_id
+			 AND tags_articles.tag_id = duplicate.tag_id AND tags_articles.id > duplicate.id`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM tags WHERE NOT EXISTS
+			 \(SELECT 1 FROM tags_articles WHERE tags_articles.tag_id = tags.id\)
+			 AND NOT EXISTS \(SELECT 1 FROM tags AS children WHERE children.parent_id = tags.id\)
+			 AND NOT EXISTS \(SELECT 1 FROM tag_aliases WHERE tag_aliases.tag_id = tags.id\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	provider := database.DBProvider{&config.Config{}, db}
+	report, err := provider.Reindex()
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+	assert.NoError(t, err, "Error")
+	assert.Equal(t, &models.ReindexReport{DuplicateMappings: 2, OrphanTags: 1}, report, "report")
+}
+
+func expectRecordTagChange(m sqlmock.Sqlmock, action string, from string, to string) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`INSERT INTO tag_audit_log \(action, from_tag, to_tag, actor\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs(action, from, to, "eve").WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectLockTag(m sqlmock.Sqlmock, name string) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`SELECT id FROM tags WHERE name = \$1 FOR UPDATE`).WithArgs(name)
+}
+
+func expectTouchTaggedArticles(m sqlmock.Sqlmock, tagID int64) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`UPDATE articles SET updated_at = now\(\)
+			     WHERE id IN \(SELECT article_id FROM tags_articles WHERE tag_id = \$1\)`).
+		WithArgs(tagID).WillReturnResult(sqlmock.NewResult(0, 2))
+}
+
+// mergeTags expects from, tag 1, to be merged into into, tag 2.
+func mergeTags(m sqlmock.Sqlmock, from string, into string) {
+	m.ExpectBegin()
+	expectLockTagHierarchy(m)
+	expectLockTag(m, from).WillReturnRows(mockedRows([]string{"id"}, []interface{}{1}))
+	expectLockTag(m, into).WillReturnRows(mockedRows([]string{"id"}, []interface{}{2}))
+	expectTouchTaggedArticles(m, 1)
+	m.ExpectExec(`UPDATE tags_articles SET tag_id = \$2 WHERE tag_id = \$1
+		      AND article_id NOT IN \(SELECT article_id FROM tags_articles WHERE tag_id = \$2\)`).
+		WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 3))
+	m.ExpectExec(`UPDATE tag_aliases SET tag_id = \$2 WHERE tag_id = \$1`).WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(`WITH RECURSIVE ancestors AS .* UPDATE tags SET parent_id = \(SELECT parent_id FROM tags WHERE id = \$2\)
+		      WHERE id = \$1 AND \$2 IN \(SELECT id FROM ancestors\)`).WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(`UPDATE tags SET parent_id = \$2 WHERE parent_id = \$1`).WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	m.ExpectExec(`DELETE FROM tags WHERE id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(`INSERT INTO tag_aliases \(alias, tag_id\) VALUES \(\$1, \$2\)`).WithArgs(from, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecordTagChange(m, "merge", from, into)
+	m.ExpectCommit()
+}
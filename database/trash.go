@@ -0,0 +1,32 @@
+package database
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func (db *DBProvider) RestoreArticle(id string) error {
+	result, err := db.Connection.Exec(`UPDATE articles SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to restore article")
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return providers.ErrNotFound
+	}
+
+	return nil
+}
+
+func (db *DBProvider) PurgeDeleted(before time.Time) (int64, error) {
+	result, err := db.Connection.Exec(`DELETE FROM articles WHERE deleted_at <= $1`, before)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to purge deleted articles")
+	}
+
+	purged, err := result.RowsAffected()
+	return purged, errors.Wrap(err, "failed to purge deleted articles")
+}
@@ -0,0 +1,113 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/database"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func TestRestoreArticle(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		ExpectedError  error
+	}{
+		{
+			Name: "Failure - article not in the trash",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectRestoreArticle(m).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			ExpectedError: providers.ErrNotFound,
+		},
+		{
+			Name: "Success - article restored",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectRestoreArticle(m).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			err = provider.RestoreArticle("123")
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			assert.Equal(t, d.ExpectedError, err, "%s: error", d.Name)
+		})
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	before := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name           string
+		MockOperations func(m sqlmock.Sqlmock)
+		ExpectedPurged int64
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name: "Failure - db error",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectPurgeDeleted(m, before).WillReturnError(errors.New("database error"))
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "failed to purge deleted articles: database error", "Error")
+			},
+		},
+		{
+			Name: "Success - expired articles purged",
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectPurgeDeleted(m, before).WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			ExpectedPurged: 3,
+		},
+	}
+
+	for _, d := range testTable {
+		t.Run(d.Name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			db := sqlx.NewDb(sqlDB, "postgres")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			provider := database.DBProvider{&config.Config{}, db}
+
+			purged, err := provider.PurgeDeleted(before)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.ExpectedPurged, purged, "%s: purged", d.Name)
+		})
+	}
+}
+
+func expectRestoreArticle(m sqlmock.Sqlmock) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`UPDATE articles SET deleted_at = NULL WHERE id = \$1 AND deleted_at IS NOT NULL`).WithArgs("123")
+}
+
+func expectPurgeDeleted(m sqlmock.Sqlmock, before time.Time) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`DELETE FROM articles WHERE deleted_at <= \$1`).WithArgs(before)
+}
@@ -24,14 +24,48 @@ func stringArrayToTags(strArray pq.StringArray) []models.Tag {
 func articleTagsPairs(article int64, tags []int64) string {
 	values := make([]string, 0, len(tags))
 
-	for _, tag := range tags {
-		value := fmt.Sprintf("(%d, %d)", article, tag)
+	for position, tag := range tags {
+		value := fmt.Sprintf("(%d, %d, %d)", article, tag, position)
 		values = append(values, value)
 	}
 
 	return strings.Join(values, ",")
 }
 
+func articlesValue(articles []*models.Article) (string, []interface{}) {
+	valueIndexes := make([]string, 0, len(articles))
+	values := make([]interface{}, 0, 8*len(articles))
+
+	for idx, article := range articles {
+		placeholders := make([]string, 0, 8)
+		for column := 1; column <= 8; column++ {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", 8*idx+column))
+		}
+
+		valueIndexes = append(valueIndexes, "("+strings.Join(placeholders, ", ")+")")
+		values = append(values, article.Title, article.Body, article.Date, article.Format, article.BodyHTML, article.Slug,
+			article.Status, article.PublishAt)
+	}
+
+	return strings.Join(valueIndexes, ","), values
+}
+
+func uniqArticleTags(articles []*models.Article) []models.Tag {
+	seen := make(map[models.Tag]bool)
+	tags := make([]models.Tag, 0)
+
+	for _, article := range articles {
+		for _, tag := range article.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
 func tagsValue(tags []models.Tag) (string, []interface{}) {
 	valueIndexes := make([]string, 0, len(tags))
 	values := make([]interface{}, 0, len(tags))
@@ -44,22 +78,62 @@ func tagsValue(tags []models.Tag) (string, []interface{}) {
 	return strings.Join(valueIndexes, ","), values
 }
 
-func toIds(rows *sqlx.Rows) ([]int64, error) {
+func tagIds(tags []models.Tag, ids map[models.Tag]int64) []int64 {
+	tagIds := make([]int64, 0, len(tags))
+
+	for _, tag := range tags {
+		tagIds = append(tagIds, ids[tag])
+	}
+
+	return tagIds
+}
+
+// Postgres returns the rows of a multi-row insert in the order of the VALUES list.
+func scanCreatedArticles(rows *sqlx.Rows, articles []*models.Article) error {
+	defer rows.Close()
+
+	idx := 0
+	for rows.Next() {
+		if idx >= len(articles) {
+			return errors.New("more rows returned than articles inserted")
+		}
+
+		article := articles[idx]
+		if err := rows.Scan(&article.ID, &article.Version, &article.CreatedAt, &article.UpdatedAt); err != nil {
+			return errors.Wrap(err, "failed to scan inserted article")
+		}
+
+		idx++
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to insert articles")
+	}
+
+	if idx != len(articles) {
+		return errors.New("fewer rows returned than articles inserted")
+	}
+
+	return nil
+}
+
+func toTagIds(rows *sqlx.Rows) (map[models.Tag]int64, error) {
 	if rows == nil {
 		return nil, errors.New("rows is nil")
 	}
 
-	ids := make([]int64, 0)
+	ids := make(map[models.Tag]int64)
 
 	defer rows.Close()
 
 	for rows.Next() {
 		var id int64
-		if err := rows.Scan(&id); err != nil {
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
 			return nil, err
 		}
 
-		ids = append(ids, id)
+		ids[models.Tag(name)] = id
 	}
 
 	if err := rows.Err(); err != nil {
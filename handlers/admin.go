@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/providers"
+)
+
+// AdminOnly closes admin routes when no token is configured.
+func AdminOnly(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			resp := &response{
+				Status: http.StatusUnauthorized,
+				err:    errors.New("a valid admin bearer token is required"),
+			}
+
+			resp.header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			sendResponse(w, r, resp)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (ah *ArticleHandler) RestoreArticle() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		vars := mux.Vars(r)
+		err := ah.Provider.RestoreArticle(vars["id"])
+
+		if errors.Cause(err) == providers.ErrNotFound {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		article, err := ah.Provider.FindArticle(vars["id"])
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		if article == nil {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		resp.value = article
+	}
+}
@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func TestAdminOnly(t *testing.T) {
+	data := []struct {
+		Name           string
+		Token          string
+		Authorization  string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - no token configured",
+			Authorization:  "Bearer ",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Failure - missing token",
+			Token:          "secret",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Failure - wrong token",
+			Token:          "secret",
+			Authorization:  "Bearer guess",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Success - matching token",
+			Token:          "secret",
+			Authorization:  "Bearer secret",
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/admin/articles/123/restore", nil)
+			assert.NoError(t, err, "failed to create request")
+			if d.Authorization != "" {
+				r.Header.Set("Authorization", d.Authorization)
+			}
+
+			handler := handlers.AdminOnly(d.Token, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+			handler(w, r)
+
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			if d.ExpectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="admin"`, w.Header().Get("WWW-Authenticate"), "WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	router := handlers.NewHandler(&config.Config{AdminToken: "secret"}, nil)
+
+	for _, path := range []string{"/export", "/admin/tags/tree", "/admin/tags/audit-log"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", path, nil)
+			assert.NoError(t, err, "failed to create request")
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "status")
+		})
+	}
+}
+
+func TestRestoreArticle(t *testing.T) {
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports"}, Title: "z1", Version: 3}
+
+	data := []struct {
+		Name           string
+		MockProvider   func(m *dataProviderMock)
+		ExpectedStatus int
+	}{
+		{
+			Name: "Failure - article not in the trash",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("RestoreArticle", "123").Return(providers.ErrNotFound)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name: "Success - article restored",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("RestoreArticle", "123").Return(nil)
+				m.OnFindArticle("123").Return(&article, nil)
+			},
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/admin/articles/123/restore", nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+
+			provider := new(dataProviderMock)
+			d.MockProvider(provider)
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+			ah.RestoreArticle()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+		})
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 
 	"github.com/eve-qunliu/articles/config"
 	"github.com/eve-qunliu/articles/models"
@@ -16,14 +20,60 @@ type response struct {
 	Status  int
 	Payload []byte
 	err     error
+	headers http.Header
+	value   interface{}
+	// cacheable responses carry an ETag, and Last-Modified when lastModified is set.
+	cacheable    bool
+	lastModified time.Time
 }
 
-func sendResponse(w http.ResponseWriter, resp *response) {
+func (resp *response) header() http.Header {
+	if resp.headers == nil {
+		resp.headers = http.Header{}
+	}
+
+	return resp.headers
+}
+
+func (resp *response) revalidate(modified time.Time) {
+	resp.cacheable = true
+	resp.lastModified = modified
+}
+
+func sendResponse(w http.ResponseWriter, r *http.Request, resp *response) {
+	if resp.Status >= http.StatusBadRequest && resp.value == nil && resp.Payload == nil {
+		describeProblem(resp, nil)
+	}
+
+	contentType := "application/json"
+	switch {
+	case resp.value != nil:
+		contentType = encodeResponse(r, resp)
+		w.Header().Set("Vary", "Accept")
+	case resp.Payload != nil:
+		// Payloads encoded earlier, such as idempotent replays, were
+		// negotiated for the same Accept header.
+		if e := negotiate(r.Header.Get("Accept"), nil); e != nil {
+			contentType = e.mediaTypes[0]
+			if resp.Status >= http.StatusBadRequest && e.problemType != "" {
+				contentType = e.problemType
+			}
+		}
+	}
+
 	if resp.err != nil {
 		logger.Errorf("failed to handle request: %s", resp.err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	if resp.cacheable && resp.Status == http.StatusOK {
+		conditional(r, resp, resp.lastModified)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	for key, values := range resp.headers {
+		w.Header()[key] = values
+	}
+
 	w.WriteHeader(resp.Status)
 	w.Write(resp.Payload)
 }
@@ -39,7 +89,7 @@ func (ah *ArticleHandler) CreateArticles() func(http.ResponseWriter, *http.Reque
 			Status: http.StatusOK,
 		}
 
-		defer sendResponse(w, resp)
+		defer sendResponse(w, r, resp)
 
 		body, err := ioutil.ReadAll(r.Body)
 
@@ -58,7 +108,8 @@ func (ah *ArticleHandler) CreateArticles() func(http.ResponseWriter, *http.Reque
 			return
 		}
 
-		err = article.Normalize(ah.Config.TagLimit)
+		article.Editor = r.Header.Get(editorHeader)
+		err = article.Normalize(ah.Config.TagLimit, ah.Config.Tags)
 
 		if err != nil {
 			resp.Status = http.StatusUnprocessableEntity
@@ -73,48 +124,248 @@ func (ah *ArticleHandler) CreateArticles() func(http.ResponseWriter, *http.Reque
 			return
 		}
 
-		payload, err := json.Marshal(article)
+		resp.Status = http.StatusCreated
+		resp.value = article
+	}
+}
+
+func (ah *ArticleHandler) FindArticle() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		vars := mux.Vars(r)
+		if _, err := strconv.ParseInt(vars["id"], 10, 64); err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = errors.Errorf("article id %q must be numeric", vars["id"])
+			return
+		}
+
+		article, err := ah.Provider.FindArticle(vars["id"])
 		if err != nil {
 			resp.Status = http.StatusInternalServerError
 			resp.err = err
 			return
 		}
 
-		resp.Status = http.StatusCreated
-		resp.Payload = payload
+		if article == nil || !article.Published() {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		presentArticle(r, resp, article)
 	}
 }
 
-func (ah *ArticleHandler) FindArticle() func(http.ResponseWriter, *http.Request) {
+// FindArticleBySlug redirects former slugs permanently to the current one.
+func (ah *ArticleHandler) FindArticleBySlug() func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		resp := &response{
 			Status: http.StatusOK,
 		}
 
-		defer sendResponse(w, resp)
+		defer sendResponse(w, r, resp)
 
-		vars := mux.Vars(r)
-		article, err := ah.Provider.FindArticle(vars["id"])
+		slug := mux.Vars(r)["slug"]
+		article, err := ah.Provider.FindArticleBySlug(slug)
 		if err != nil {
 			resp.Status = http.StatusInternalServerError
 			resp.err = err
 			return
 		}
 
-		if article == nil {
+		if article == nil || !article.Published() {
 			resp.Status = http.StatusNotFound
 			return
 		}
 
-		payload, err := json.Marshal(article)
+		if article.Slug != slug {
+			location := url.URL{Path: "/articles/by-slug/" + article.Slug, RawQuery: r.URL.RawQuery}
+			resp.Status = http.StatusMovedPermanently
+			resp.header().Set("Location", location.String())
+			return
+		}
+
+		presentArticle(r, resp, article)
+	}
+}
+
+func presentArticle(r *http.Request, resp *response, article *models.Article) {
+	switch r.URL.Query().Get("render") {
+	case "":
+	case "html":
+		article.Body = article.HTML()
+	default:
+		resp.Status = http.StatusBadRequest
+		resp.err = errors.Errorf("render %q is not supported, use html", r.URL.Query().Get("render"))
+		return
+	}
+
+	resp.Status = http.StatusOK
+	resp.value = article
+	resp.revalidate(article.UpdatedAt)
+}
+
+func (ah *ArticleHandler) UpdateArticle() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		vars := mux.Vars(r)
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+
 		if err != nil {
 			resp.Status = http.StatusInternalServerError
 			resp.err = err
 			return
 		}
 
+		article := &models.Article{}
+		err = json.Unmarshal(body, article)
+
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		article.Editor = r.Header.Get(editorHeader)
+		err = article.Normalize(ah.Config.TagLimit, ah.Config.Tags)
+
+		if err != nil {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = err
+			return
+		}
+
+		article.ID = id
+		article.Version, resp.Status, resp.err = ah.expectedVersion(r, vars["id"], article.Version)
+
+		if resp.Status != http.StatusOK {
+			return
+		}
+
+		err = ah.Provider.UpdateArticle(article)
+
+		if err != nil {
+			resp.Status = writeErrorStatus(r, err)
+			resp.err = err
+			return
+		}
+
 		resp.Status = http.StatusOK
-		resp.Payload = payload
+		resp.value = article
+	}
+}
+
+func (ah *ArticleHandler) DeleteArticle() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		version, err := queryVersion(r)
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		vars := mux.Vars(r)
+		version, resp.Status, resp.err = ah.expectedVersion(r, vars["id"], version)
+
+		if resp.Status != http.StatusOK {
+			return
+		}
+
+		err = ah.Provider.DeleteArticle(vars["id"], version)
+
+		if err != nil {
+			resp.Status = writeErrorStatus(r, err)
+			resp.err = err
+			return
+		}
+
+		resp.Status = http.StatusNoContent
+	}
+}
+
+func queryVersion(r *http.Request) (int64, error) {
+	query := r.URL.Query().Get("version")
+	if query == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(query, 10, 64)
+}
+
+// expectedVersion resolves the version a write is conditioned on from version or If-Match.
+func (ah *ArticleHandler) expectedVersion(r *http.Request, id string, version int64) (int64, int, error) {
+	if r.Header.Get("If-Match") == "" {
+		if version == 0 {
+			return 0, http.StatusPreconditionRequired, errors.New("version or If-Match header is required")
+		}
+
+		return version, http.StatusOK, nil
+	}
+
+	current, err := ah.Provider.FindArticle(id)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+
+	if current == nil {
+		return 0, http.StatusNotFound, nil
+	}
+
+	payload, err := representation(r, current)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+
+	if preconditionFailed(r, entityTag(payload)) {
+		return 0, http.StatusPreconditionFailed, errors.New("If-Match precondition failed")
+	}
+
+	if version != 0 && version != current.Version {
+		return 0, http.StatusConflict, providers.ErrConflict
+	}
+
+	return current.Version, http.StatusOK, nil
+}
+
+// Stale versions are a 412 when conditioned on If-Match and a 409 otherwise.
+func writeErrorStatus(r *http.Request, err error) int {
+	if _, ok := errors.Cause(err).(*models.TransitionError); ok {
+		return http.StatusUnprocessableEntity
+	}
+
+	switch errors.Cause(err) {
+	case providers.ErrNotFound:
+		return http.StatusNotFound
+	case providers.ErrConflict:
+		if r.Header.Get("If-Match") != "" {
+			return http.StatusPreconditionFailed
+		}
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
@@ -124,18 +375,28 @@ func (ah *ArticleHandler) FindTag() func(http.ResponseWriter, *http.Request) {
 			Status: http.StatusOK,
 		}
 
-		defer sendResponse(w, resp)
+		defer sendResponse(w, r, resp)
 
 		vars := mux.Vars(r)
-		article, err := ah.Provider.FindTag(vars["tagName"], formatDate(vars["date"]))
+		date, err := parseDate(vars["date"], time.Now())
 
 		if err != nil {
-			resp.Status = http.StatusInternalServerError
+			resp.Status = http.StatusBadRequest
 			resp.err = err
 			return
 		}
 
-		payload, err := json.Marshal(article)
+		tag, err := ah.Config.Tags.Normalize(models.Tag(vars["tagName"]))
+
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		descendants := r.URL.Query().Get("include_descendants") == "true"
+		tagArticles, err := ah.Provider.FindTag(string(tag), date, descendants)
+
 		if err != nil {
 			resp.Status = http.StatusInternalServerError
 			resp.err = err
@@ -143,14 +404,28 @@ func (ah *ArticleHandler) FindTag() func(http.ResponseWriter, *http.Request) {
 		}
 
 		resp.Status = http.StatusOK
-		resp.Payload = payload
+		resp.value = tagArticles
+		// No Last-Modified: deletions and tag changes can leave the newest
+		// update time where it was, so only the ETag tracks the listing.
+		resp.cacheable = true
 	}
 }
 
-func formatDate(date string) string {
-	if len(date) != len("YYYYMMDD") {
-		return date
+func parseDate(date string, now time.Time) (string, error) {
+	const day = "2006-01-02"
+
+	switch date {
+	case "today":
+		return now.UTC().Format(day), nil
+	case "yesterday":
+		return now.UTC().AddDate(0, 0, -1).Format(day), nil
+	}
+
+	for _, layout := range []string{"20060102", day} {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed.Format(day), nil
+		}
 	}
 
-	return date[:4] + "-" + date[4:6] + "-" + date[6:]
+	return "", errors.Errorf("date %q must have format YYYYMMDD or YYYY-MM-DD, or be today or yesterday", date)
 }
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/lib/pq"
@@ -17,12 +18,19 @@ import (
 	"github.com/eve-qunliu/articles/config"
 	"github.com/eve-qunliu/articles/handlers"
 	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
 )
 
 type dataProviderMock struct {
 	mock.Mock
 }
 
+type providerMock struct {
+	*dataProviderMock
+	*idempotencyStoreMock
+	*tagAdminMock
+}
+
 func (m *dataProviderMock) CreateArticle(a *models.Article) error {
 	rtn := m.Called(a)
 	return rtn.Error(0)
@@ -32,6 +40,39 @@ func (m *dataProviderMock) OnCreateArticle(a *models.Article) *mock.Call {
 	return m.On("CreateArticle", mock.MatchedBy(equalArticle(a)))
 }
 
+func (m *dataProviderMock) CreateArticles(articles []*models.Article) error {
+	rtn := m.Called(articles)
+	return rtn.Error(0)
+}
+
+func (m *dataProviderMock) ExportArticles(filter models.ExportFilter, fn func(*models.Article) error) error {
+	rtn := m.Called(filter)
+	for _, article := range rtn.Get(0).([]*models.Article) {
+		if err := fn(article); err != nil {
+			return err
+		}
+	}
+	return rtn.Error(1)
+}
+
+func (m *dataProviderMock) UpdateArticle(a *models.Article) error {
+	rtn := m.Called(a)
+	return rtn.Error(0)
+}
+
+func (m *dataProviderMock) OnUpdateArticle(a *models.Article) *mock.Call {
+	return m.On("UpdateArticle", mock.MatchedBy(equalArticle(a)))
+}
+
+func (m *dataProviderMock) DeleteArticle(id string, version int64) error {
+	rtn := m.Called(id, version)
+	return rtn.Error(0)
+}
+
+func (m *dataProviderMock) OnDeleteArticle(id string, version int64) *mock.Call {
+	return m.On("DeleteArticle", id, version)
+}
+
 func (m *dataProviderMock) FindArticle(id string) (*models.Article, error) {
 	rtn := m.Called(id)
 	return rtn.Get(0).(*models.Article), rtn.Error(1)
@@ -41,18 +82,67 @@ func (m *dataProviderMock) OnFindArticle(id string) *mock.Call {
 	return m.On("FindArticle", id)
 }
 
-func (m *dataProviderMock) FindTag(name, date string) (*models.TagArticles, error) {
-	rtn := m.Called(name, date)
+func (m *dataProviderMock) FindArticleBySlug(slug string) (*models.Article, error) {
+	rtn := m.Called(slug)
+	return rtn.Get(0).(*models.Article), rtn.Error(1)
+}
+
+func (m *dataProviderMock) RestoreArticle(id string) error {
+	rtn := m.Called(id)
+	return rtn.Error(0)
+}
+
+func (m *dataProviderMock) ArticleRevisions(id string) ([]models.Revision, error) {
+	rtn := m.Called(id)
+	return rtn.Get(0).([]models.Revision), rtn.Error(1)
+}
+
+func (m *dataProviderMock) ArticleRevision(id string, number int) (*models.Revision, error) {
+	rtn := m.Called(id, number)
+	return rtn.Get(0).(*models.Revision), rtn.Error(1)
+}
+
+func (m *dataProviderMock) FindTag(name, date string, descendants bool) (*models.TagArticles, error) {
+	rtn := m.Called(name, date, descendants)
 	return rtn.Get(0).(*models.TagArticles), rtn.Error(1)
 }
 
 func (m *dataProviderMock) OnFindTag(name, date string) *mock.Call {
-	return m.On("FindTag", name, date)
+	return m.On("FindTag", name, date, false)
+}
+
+func (m *dataProviderMock) SuggestTags(prefix string, since string, limit int) ([]models.TagSuggestion, error) {
+	rtn := m.Called(prefix, since, limit)
+	return rtn.Get(0).([]models.TagSuggestion), rtn.Error(1)
+}
+
+func (m *dataProviderMock) LatestArticles(tag string, limit int) ([]*models.Article, error) {
+	rtn := m.Called(tag, limit)
+	return rtn.Get(0).([]*models.Article), rtn.Error(1)
+}
+
+func (m *dataProviderMock) OnLatestArticles(tag string, limit int) *mock.Call {
+	return m.On("LatestArticles", tag, limit)
+}
+
+func (m *dataProviderMock) SitemapPages(pageSize int) ([]models.SitemapPage, error) {
+	rtn := m.Called(pageSize)
+	return rtn.Get(0).([]models.SitemapPage), rtn.Error(1)
+}
+
+func (m *dataProviderMock) SitemapEntries(page int, pageSize int, fn func(models.SitemapEntry) error) error {
+	rtn := m.Called(page, pageSize)
+	for _, entry := range rtn.Get(0).([]models.SitemapEntry) {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rtn.Error(1)
 }
 
 func equalArticle(expected *models.Article) func(a *models.Article) bool {
 	return func(a *models.Article) bool {
-		if expected.Body != a.Body || expected.Title != a.Title || expected.Date != a.Date {
+		if expected.Body != a.Body || expected.Title != a.Title || expected.Date != a.Date || expected.Version != a.Version {
 			return false
 		}
 		if !reflect.DeepEqual(expected.Tags, a.Tags) {
@@ -158,14 +248,29 @@ func TestCreateArticles(t *testing.T) {
 }
 
 func TestFindArticles(t *testing.T) {
-	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"music", "sports"}, Title: "z1"}
+	updatedAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"music", "sports"}, Title: "z1", UpdatedAt: updatedAt}
+	rendered := models.Article{Body: "*z3*", BodyHTML: "<p><em>z3</em></p>\n", Date: "2018-06-12", Format: models.FormatMarkdown, ID: 123,
+		Tags: []models.Tag{"music"}, Title: "z1", UpdatedAt: updatedAt}
+	unrendered := models.Article{Body: "*z3*", Date: "2018-06-12", Format: models.FormatMarkdown, ID: 123,
+		Tags: []models.Tag{"music"}, Title: "z1", UpdatedAt: updatedAt}
 
 	data := []struct {
 		Name            string
+		ID              string
+		Query           string
 		Article         *models.Article
+		Headers         map[string]string
 		ExpectedStatus  int
+		ExpectedBody    string
 		MockFindArticle func(m *dataProviderMock, id string, rtn *models.Article)
 	}{
+		{
+			Name:           "Failure - non numeric id",
+			ID:             "abc",
+			Article:        (*models.Article)(nil),
+			ExpectedStatus: http.StatusBadRequest,
+		},
 		{
 			Name:           "Failure - query error",
 			Article:        (*models.Article)(nil),
@@ -190,15 +295,94 @@ func TestFindArticles(t *testing.T) {
 				m.OnFindArticle(id).Return(a, nil)
 			},
 		},
+		{
+			Name:           "Failure - draft hidden from readers",
+			Article:        &models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Status: models.StatusDraft, Title: "z1"},
+			ExpectedStatus: http.StatusNotFound,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Failure - unsupported rendering",
+			Query:          "?render=pdf",
+			Article:        (*models.Article)(&article),
+			ExpectedStatus: http.StatusBadRequest,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Success - source body",
+			Article:        (*models.Article)(&rendered),
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"body":"*z3*"`,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Success - rendered body",
+			Query:          "?render=html",
+			Article:        (*models.Article)(&rendered),
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"body":"\u003cp\u003e\u003cem\u003ez3\u003c/em\u003e\u003c/p\u003e\n"`,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Success - body rendered on read",
+			Query:          "?render=html",
+			Article:        (*models.Article)(&unrendered),
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"body":"\u003cp\u003e\u003cem\u003ez3\u003c/em\u003e\u003c/p\u003e\n"`,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Success - stale etag",
+			Article:        (*models.Article)(&article),
+			Headers:        map[string]string{"If-None-Match": `"stale"`},
+			ExpectedStatus: http.StatusOK,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Success - not modified by etag",
+			Article:        (*models.Article)(&article),
+			Headers:        map[string]string{"If-None-Match": "*"},
+			ExpectedStatus: http.StatusNotModified,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
+		{
+			Name:           "Success - not modified since",
+			Article:        (*models.Article)(&article),
+			Headers:        map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			ExpectedStatus: http.StatusNotModified,
+			MockFindArticle: func(m *dataProviderMock, id string, a *models.Article) {
+				m.OnFindArticle(id).Return(a, nil)
+			},
+		},
 	}
 
 	for _, d := range data {
 		t.Run(d.Name, func(t *testing.T) {
 			id := "123"
+			if d.ID != "" {
+				id = d.ID
+			}
 			w := httptest.NewRecorder()
-			r, err := http.NewRequest("GET", "", nil)
+			r, err := http.NewRequest("GET", "/articles/"+id+d.Query, nil)
 			assert.NoError(t, err, "failed to create request")
 			r = mux.SetURLVars(r, map[string]string{"id": id})
+			for key, value := range d.Headers {
+				r.Header.Set(key, value)
+			}
 
 			provider := new(dataProviderMock)
 			if d.MockFindArticle != nil {
@@ -209,6 +393,233 @@ func TestFindArticles(t *testing.T) {
 			ah := handlers.ArticleHandler{Config: &config, Provider: provider}
 			handler := ah.FindArticle()
 
+			handler(w, r)
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "expectedStatus code")
+			if d.ExpectedBody != "" {
+				assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+			}
+			if d.Article != nil && d.ExpectedStatus != http.StatusBadRequest && d.ExpectedStatus != http.StatusNotFound {
+				assert.NotEmpty(t, w.Header().Get("ETag"), "ETag header")
+				assert.Equal(t, updatedAt.Format(http.TimeFormat), w.Header().Get("Last-Modified"), "Last-Modified header")
+			}
+		})
+	}
+}
+
+func TestFindArticleBySlug(t *testing.T) {
+	article := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Slug: "z1", Tags: []models.Tag{"sports"}, Title: "z1"}
+
+	data := []struct {
+		Name             string
+		Slug             string
+		Query            string
+		Article          *models.Article
+		Err              error
+		ExpectedStatus   int
+		ExpectedLocation string
+	}{
+		{
+			Name:           "Failure - query error",
+			Slug:           "z1",
+			Article:        (*models.Article)(nil),
+			Err:            errors.New("unknown error"),
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:           "Failure - unknown slug",
+			Slug:           "z2",
+			Article:        (*models.Article)(nil),
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Success - current slug",
+			Slug:           "z1",
+			Article:        &article,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:             "Success - former slug redirects",
+			Slug:             "z0",
+			Query:            "?render=html",
+			Article:          &article,
+			ExpectedStatus:   http.StatusMovedPermanently,
+			ExpectedLocation: "/articles/by-slug/z1?render=html",
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/articles/by-slug/"+d.Slug+d.Query, nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"slug": d.Slug})
+
+			provider := new(dataProviderMock)
+			provider.On("FindArticleBySlug", d.Slug).Return(d.Article, d.Err)
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+			ah.FindArticleBySlug()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Equal(t, d.ExpectedLocation, w.Header().Get("Location"), "Location header")
+			if d.ExpectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"slug":"z1"`, "body")
+			}
+		})
+	}
+}
+
+func TestUpdateArticle(t *testing.T) {
+	current := models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports"}, Title: "z1", Version: 2}
+	updated := models.Article{Body: "z4", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports"}, Title: "z1", Version: 2}
+	data := []struct {
+		Name           string
+		Headers        map[string]string
+		ExpectedStatus int
+		MockProvider   func(m *dataProviderMock)
+		Payload        string
+	}{
+		{
+			Name:           "Failure - invalid article payload",
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			Payload:        `{"body":"z4","date":"2018-06-12","tags":["sports"],"version":2}`,
+		},
+		{
+			Name:           "Failure - missing version",
+			ExpectedStatus: http.StatusPreconditionRequired,
+			Payload:        `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"]}`,
+		},
+		{
+			Name:           "Failure - stale version",
+			ExpectedStatus: http.StatusConflict,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnUpdateArticle(&updated).Return(providers.ErrConflict)
+			},
+			Payload: `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"],"version":2}`,
+		},
+		{
+			Name:           "Failure - article not exist",
+			ExpectedStatus: http.StatusNotFound,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnUpdateArticle(&updated).Return(errors.Wrap(providers.ErrNotFound, "failed"))
+			},
+			Payload: `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"],"version":2}`,
+		},
+		{
+			Name:           "Failure - status transition not allowed",
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnUpdateArticle(&updated).Return(&models.TransitionError{From: models.StatusPublished, To: models.StatusDraft})
+			},
+			Payload: `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"],"status":"draft","version":2}`,
+		},
+		{
+			Name:           "Failure - If-Match does not match",
+			Headers:        map[string]string{"If-Match": `"stale"`},
+			ExpectedStatus: http.StatusPreconditionFailed,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&current, nil)
+			},
+			Payload: `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"]}`,
+		},
+		{
+			Name:           "Success - update with If-Match",
+			Headers:        map[string]string{"If-Match": "*"},
+			ExpectedStatus: http.StatusOK,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&current, nil)
+				m.OnUpdateArticle(&updated).Return(nil)
+			},
+			Payload: `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"]}`,
+		},
+		{
+			Name:           "Success - update with version",
+			ExpectedStatus: http.StatusOK,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnUpdateArticle(&updated).Return(nil)
+			},
+			Payload: `{"title":"z1","body":"z4","date":"2018-06-12","tags":["sports"],"version":2}`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("PUT", "", strings.NewReader(d.Payload))
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			for key, value := range d.Headers {
+				r.Header.Set(key, value)
+			}
+
+			provider := new(dataProviderMock)
+			if d.MockProvider != nil {
+				d.MockProvider(provider)
+			}
+
+			config := config.Config{TagLimit: 3}
+			ah := handlers.ArticleHandler{Config: &config, Provider: provider}
+			handler := ah.UpdateArticle()
+
+			handler(w, r)
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "expectedStatus code")
+		})
+	}
+}
+
+func TestDeleteArticle(t *testing.T) {
+	data := []struct {
+		Name           string
+		Query          string
+		ExpectedStatus int
+		MockProvider   func(m *dataProviderMock)
+	}{
+		{
+			Name:           "Failure - invalid version",
+			Query:          "?version=abc",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Failure - missing version",
+			ExpectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			Name:           "Failure - stale version",
+			Query:          "?version=1",
+			ExpectedStatus: http.StatusConflict,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnDeleteArticle("123", 1).Return(providers.ErrConflict)
+			},
+		},
+		{
+			Name:           "Success - delete article",
+			Query:          "?version=2",
+			ExpectedStatus: http.StatusNoContent,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnDeleteArticle("123", 2).Return(nil)
+			},
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("DELETE", "/articles/123"+d.Query, nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+
+			provider := new(dataProviderMock)
+			if d.MockProvider != nil {
+				d.MockProvider(provider)
+			}
+
+			config := config.Config{TagLimit: 3}
+			ah := handlers.ArticleHandler{Config: &config, Provider: provider}
+			handler := ah.DeleteArticle()
+
 			handler(w, r)
 			provider.Mock.AssertExpectations(t)
 			assert.Equal(t, d.ExpectedStatus, w.Code, "expectedStatus code")
@@ -220,40 +631,87 @@ func TestFindTag(t *testing.T) {
 	tagArticles := models.TagArticles{Articles: pq.StringArray{"1", "2"}, Count: 2, RelatedTags: pq.StringArray{"music", "sports"}, Tag: "sports"}
 	data := []struct {
 		Name           string
+		Date           string
+		Query          string
+		ExpectedDate   string
 		TagArticles    *models.TagArticles
 		ExpectedStatus int
 		MockFindTag    func(m *dataProviderMock, tag, date string, rtn *models.TagArticles)
 	}{
 		{
 			Name:           "Failure - query error",
+			Date:           "2018-06-12",
+			ExpectedDate:   "2018-06-12",
 			TagArticles:    (*models.TagArticles)(nil),
 			ExpectedStatus: http.StatusInternalServerError,
 			MockFindTag: func(m *dataProviderMock, tag, date string, rtn *models.TagArticles) {
 				m.OnFindTag(tag, date).Return(rtn, errors.New("unknown error"))
 			},
 		},
+		{
+			Name:           "Failure - garbage date",
+			Date:           "garbage",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Failure - impossible date",
+			Date:           "20181345",
+			ExpectedStatus: http.StatusBadRequest,
+		},
 		{
 			Name:           "Success - find tag articles",
+			Date:           "2018-06-12",
+			ExpectedDate:   "2018-06-12",
+			TagArticles:    &tagArticles,
+			ExpectedStatus: http.StatusOK,
+			MockFindTag: func(m *dataProviderMock, tag, date string, rtn *models.TagArticles) {
+				m.OnFindTag(tag, date).Return(rtn, nil)
+			},
+		},
+		{
+			Name:           "Success - compact date",
+			Date:           "20180612",
+			ExpectedDate:   "2018-06-12",
 			TagArticles:    &tagArticles,
 			ExpectedStatus: http.StatusOK,
 			MockFindTag: func(m *dataProviderMock, tag, date string, rtn *models.TagArticles) {
 				m.OnFindTag(tag, date).Return(rtn, nil)
 			},
 		},
+		{
+			Name:           "Success - yesterday",
+			Date:           "yesterday",
+			ExpectedDate:   time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02"),
+			TagArticles:    &tagArticles,
+			ExpectedStatus: http.StatusOK,
+			MockFindTag: func(m *dataProviderMock, tag, date string, rtn *models.TagArticles) {
+				m.OnFindTag(tag, date).Return(rtn, nil)
+			},
+		},
+		{
+			Name:           "Success - descendants included",
+			Date:           "2018-06-12",
+			Query:          "?include_descendants=true",
+			ExpectedDate:   "2018-06-12",
+			TagArticles:    &tagArticles,
+			ExpectedStatus: http.StatusOK,
+			MockFindTag: func(m *dataProviderMock, tag, date string, rtn *models.TagArticles) {
+				m.On("FindTag", tag, date, true).Return(rtn, nil)
+			},
+		},
 	}
 
 	for _, d := range data {
 		t.Run(d.Name, func(t *testing.T) {
 			tag := "sports"
-			date := "2018-06-12"
 			w := httptest.NewRecorder()
-			r, err := http.NewRequest("GET", "", nil)
+			r, err := http.NewRequest("GET", d.Query, nil)
 			assert.NoError(t, err, "failed to create request")
-			r = mux.SetURLVars(r, map[string]string{"tagName": tag, "date": date})
+			r = mux.SetURLVars(r, map[string]string{"tagName": tag, "date": d.Date})
 
 			provider := new(dataProviderMock)
 			if d.MockFindTag != nil {
-				d.MockFindTag(provider, tag, date, d.TagArticles)
+				d.MockFindTag(provider, tag, d.ExpectedDate, d.TagArticles)
 			}
 
 			config := config.Config{TagLimit: 3}
@@ -266,3 +724,47 @@ func TestFindTag(t *testing.T) {
 		})
 	}
 }
+
+func TestFindTagNormalized(t *testing.T) {
+	tags, err := models.NewTagPipeline([]string{"trim", "casefold", "hyphenate", "banned"}, models.TagRules{BannedWords: []string{"spam"}})
+	assert.NoError(t, err, "pipeline")
+
+	data := []struct {
+		Name           string
+		Tag            string
+		ExpectedTag    string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Success - tag normalized",
+			Tag:            " New York ",
+			ExpectedTag:    "new-york",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Failure - tag rejected",
+			Tag:            "spam",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "", nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"tagName": d.Tag, "date": "2018-06-12"})
+
+			provider := new(dataProviderMock)
+			if d.ExpectedTag != "" {
+				provider.OnFindTag(d.ExpectedTag, "2018-06-12").Return(&models.TagArticles{Tag: d.ExpectedTag}, nil)
+			}
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3, Tags: tags}, Provider: provider}
+			ah.FindTag()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "expectedStatus code")
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func entityTag(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+func conditional(r *http.Request, resp *response, modified time.Time) {
+	etag := entityTag(resp.Payload)
+	resp.header().Set("ETag", etag)
+
+	if !modified.IsZero() {
+		resp.header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		resp.Status = http.StatusNotModified
+		resp.Payload = nil
+	}
+}
+
+// notModified only evaluates If-Modified-Since without If-None-Match.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return matchETag(header, etag, false)
+	}
+
+	header := r.Header.Get("If-Modified-Since")
+	if header == "" || modified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
+
+func preconditionFailed(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return false
+	}
+
+	return !matchETag(header, etag, true)
+}
+
+func matchETag(header string, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+// encoder renders response values; the first media type is announced in Content-Type.
+type encoder struct {
+	mediaTypes  []string
+	problemType string
+	encode      func(value interface{}) ([]byte, error)
+	supports    func(value interface{}) bool
+}
+
+func (e *encoder) contentType(value interface{}) string {
+	if _, ok := value.(*problem); ok && e.problemType != "" {
+		return e.problemType
+	}
+
+	return e.mediaTypes[0]
+}
+
+var encoders []*encoder
+
+// The first registered encoder is the default when the client accepts anything.
+func registerEncoder(e *encoder) {
+	encoders = append(encoders, e)
+}
+
+func init() {
+	registerEncoder(&encoder{
+		mediaTypes:  []string{"application/json"},
+		problemType: "application/problem+json",
+		encode:      json.Marshal,
+	})
+	registerEncoder(&encoder{
+		mediaTypes:  []string{"application/xml", "text/xml"},
+		problemType: "application/problem+xml",
+		encode:      encodeXML,
+	})
+	registerEncoder(&encoder{
+		mediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"},
+		encode:     encodeYAML,
+	})
+	registerEncoder(&encoder{
+		mediaTypes: []string{"text/csv"},
+		encode:     encodeCSV,
+		supports:   tabular,
+	})
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func negotiate(accept string, value interface{}) *encoder {
+	for _, accepted := range mediaRanges(accept) {
+		for _, e := range encoders {
+			if e.supports != nil && !e.supports(value) {
+				continue
+			}
+
+			for _, mediaType := range e.mediaTypes {
+				if matchMediaType(accepted.mediaType, mediaType) {
+					return e
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func mediaRanges(accept string) []mediaRange {
+	if strings.TrimSpace(accept) == "" {
+		return []mediaRange{{"*/*", 1}}
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	return ranges
+}
+
+func matchMediaType(accepted string, mediaType string) bool {
+	if accepted == "*/*" || accepted == mediaType {
+		return true
+	}
+
+	return strings.HasSuffix(accepted, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(accepted, "*"))
+}
+
+func encodeResponse(r *http.Request, resp *response) string {
+	_, isProblem := resp.value.(*problem)
+
+	e := negotiate(r.Header.Get("Accept"), resp.value)
+	if e == nil && !isProblem {
+		resp.Status = http.StatusNotAcceptable
+		resp.err = errors.Errorf("cannot respond with any of %s", r.Header.Get("Accept"))
+		describeProblem(resp, nil)
+		return encodeResponse(r, resp)
+	}
+
+	if e == nil {
+		e = encoders[0]
+	}
+
+	payload, err := e.encode(resp.value)
+	if err != nil && !isProblem {
+		resp.Status = http.StatusInternalServerError
+		resp.err = errors.Wrap(err, "failed to encode response")
+		describeProblem(resp, nil)
+		return encodeResponse(r, resp)
+	}
+
+	resp.Payload = payload
+	return e.contentType(resp.value)
+}
+
+// representation encodes value the way a response to r would, so entity tags match.
+func representation(r *http.Request, value interface{}) ([]byte, error) {
+	e := negotiate(r.Header.Get("Accept"), value)
+	if e == nil {
+		e = encoders[0]
+	}
+
+	return e.encode(value)
+}
+
+func encodeXML(value interface{}) ([]byte, error) {
+	payload, err := xml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), payload...), nil
+}
+
+// encodeYAML goes through JSON so YAML documents carry the same field names.
+func encodeYAML(value interface{}) ([]byte, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	if err = yaml.Unmarshal(payload, &document); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(document)
+}
+
+func tabular(value interface{}) bool {
+	switch value.(type) {
+	case *models.TagArticles, *models.ImportReport, *models.Revisions, *models.TagSuggestions, *models.TagAliases, *models.TagChanges:
+		return true
+	default:
+		return false
+	}
+}
+
+func encodeCSV(value interface{}) ([]byte, error) {
+	var records [][]string
+
+	switch v := value.(type) {
+	case *models.TagArticles:
+		records = [][]string{
+			{"tag", "count", "articles", "related_tags"},
+			{v.Tag, strconv.Itoa(v.Count), strings.Join(v.Articles, ";"), strings.Join(v.RelatedTags, ";")},
+		}
+	case *models.ImportReport:
+		records = [][]string{{"index", "id", "errors"}}
+		for _, result := range v.Results {
+			id := ""
+			if result.ID != 0 {
+				id = strconv.FormatInt(result.ID, 10)
+			}
+			records = append(records, []string{strconv.Itoa(result.Index), id, strings.Join(result.Errors, ";")})
+		}
+	case *models.Revisions:
+		records = [][]string{{"number", "created_at", "editor", "title", "date", "format", "tags", "body"}}
+		for _, revision := range v.Revisions {
+			records = append(records, []string{
+				strconv.Itoa(revision.Number), revision.CreatedAt.Format(time.RFC3339), revision.Editor, revision.Title,
+				revision.Date, revision.Format, joinTags(revision.Tags), revision.Body,
+			})
+		}
+	case *models.TagSuggestions:
+		records = [][]string{{"name", "count"}}
+		for _, suggestion := range v.Tags {
+			records = append(records, []string{string(suggestion.Name), strconv.Itoa(suggestion.Count)})
+		}
+	case *models.TagAliases:
+		records = [][]string{{"alias", "tag", "created_at"}}
+		for _, alias := range v.Aliases {
+			records = append(records, []string{alias.Alias, string(alias.Tag), alias.CreatedAt.Format(time.RFC3339)})
+		}
+	case *models.TagChanges:
+		records = [][]string{{"id", "action", "from", "to", "actor", "created_at"}}
+		for _, change := range v.Changes {
+			records = append(records, []string{
+				strconv.FormatInt(change.ID, 10), change.Action, change.From, change.To, change.Actor, change.CreatedAt.Format(time.RFC3339),
+			})
+		}
+	default:
+		return nil, errors.Errorf("cannot render %T as CSV", value)
+	}
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func joinTags(tags []models.Tag) string {
+	names := make([]string, len(tags))
+	for idx, tag := range tags {
+		names[idx] = string(tag)
+	}
+
+	return strings.Join(names, ";")
+}
@@ -0,0 +1,173 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	tagArticles := &models.TagArticles{Articles: pq.StringArray{"1", "2"}, Count: 2, RelatedTags: pq.StringArray{"music", "sports"}, Tag: "sports"}
+	article := &models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports"}, Title: "z1"}
+
+	data := []struct {
+		Name                string
+		Accept              string
+		Article             bool
+		ExpectedStatus      int
+		ExpectedContentType string
+		ExpectedBody        string
+	}{
+		{
+			Name:                "Success - JSON by default",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/json",
+			ExpectedBody:        `{"articles":["1","2"],"count":2,"related_tags":["music","sports"],"tag":"sports"}`,
+		},
+		{
+			Name:                "Success - XML",
+			Accept:              "application/xml",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/xml",
+			ExpectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<tag_articles><articles><id>1</id><id>2</id></articles><count>2</count>` +
+				`<related_tags><tag>music</tag><tag>sports</tag></related_tags><tag>sports</tag></tag_articles>`,
+		},
+		{
+			Name:                "Success - YAML",
+			Accept:              "text/html;q=0.9, application/yaml",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/yaml",
+			ExpectedBody:        "articles:\n- \"1\"\n- \"2\"\ncount: 2\nrelated_tags:\n- music\n- sports\ntag: sports\n",
+		},
+		{
+			Name:                "Success - CSV",
+			Accept:              "application/json;q=0.5, text/csv;q=0.8",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/csv",
+			ExpectedBody:        "tag,count,articles,related_tags\nsports,2,1;2,music;sports\n",
+		},
+		{
+			Name:                "Failure - CSV for a single article",
+			Accept:              "text/csv",
+			Article:             true,
+			ExpectedStatus:      http.StatusNotAcceptable,
+			ExpectedContentType: "application/problem+json",
+			ExpectedBody:        `{"type":"about:blank","title":"Not Acceptable","status":406,"detail":"cannot respond with any of text/csv"}`,
+		},
+		{
+			Name:                "Failure - unsupported media type",
+			Accept:              "image/png",
+			ExpectedStatus:      http.StatusNotAcceptable,
+			ExpectedContentType: "application/problem+json",
+			ExpectedBody:        `{"type":"about:blank","title":"Not Acceptable","status":406,"detail":"cannot respond with any of image/png"}`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "", nil)
+			require.NoError(t, err, "failed to create request")
+			if d.Accept != "" {
+				r.Header.Set("Accept", d.Accept)
+			}
+
+			provider := new(dataProviderMock)
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+
+			if d.Article {
+				r = mux.SetURLVars(r, map[string]string{"id": "123"})
+				provider.OnFindArticle("123").Return(article, nil)
+				ah.FindArticle()(w, r)
+			} else {
+				r = mux.SetURLVars(r, map[string]string{"tagName": "sports", "date": "2018-06-12"})
+				provider.OnFindTag("sports", "2018-06-12").Return(tagArticles, nil)
+				ah.FindTag()(w, r)
+			}
+
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Equal(t, d.ExpectedContentType, w.Header().Get("Content-Type"), "content type")
+			assert.Equal(t, d.ExpectedBody, w.Body.String(), "body")
+		})
+	}
+}
+
+func TestProblemNegotiation(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "", nil)
+	require.NoError(t, err, "failed to create request")
+	r.Header.Set("Accept", "application/xml")
+	r = mux.SetURLVars(r, map[string]string{"id": "abc"})
+
+	ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: new(dataProviderMock)}
+	ah.FindArticle()(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "status")
+	assert.Equal(t, "application/problem+xml", w.Header().Get("Content-Type"), "content type")
+	assert.Contains(t, w.Body.String(), `<problem xmlns="urn:ietf:rfc:7807">`, "body")
+	assert.Contains(t, w.Body.String(), `<detail>article id &#34;abc&#34; must be numeric</detail>`, "body")
+}
+
+func TestListCSV(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	data := []struct {
+		Name         string
+		MockProvider func(m *dataProviderMock)
+		Handler      func(ah *handlers.ArticleHandler) func(http.ResponseWriter, *http.Request)
+		ExpectedBody string
+	}{
+		{
+			Name: "Success - revisions",
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevisions", "123").Return([]models.Revision{
+					{ArticleID: 123, Body: "z3, z4", CreatedAt: createdAt, Date: "2018-06-12", Editor: "eve", Format: "plain", Number: 1, Tags: []models.Tag{"sports", "music"}, Title: "z1"},
+				}, nil)
+			},
+			Handler: (*handlers.ArticleHandler).ArticleRevisions,
+			ExpectedBody: "number,created_at,editor,title,date,format,tags,body\n" +
+				"1,2018-06-12T10:00:00Z,eve,z1,2018-06-12,plain,sports;music,\"z3, z4\"\n",
+		},
+		{
+			Name: "Success - tag suggestions",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("SuggestTags", "foo", "", 5).Return([]models.TagSuggestion{{Count: 4, Name: "football"}, {Count: 1, Name: "foo"}}, nil)
+			},
+			Handler:      (*handlers.ArticleHandler).SuggestTags,
+			ExpectedBody: "name,count\nfootball,4\nfoo,1\n",
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "?prefix=foo", nil)
+			require.NoError(t, err, "failed to create request")
+			r.Header.Set("Accept", "text/csv")
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+
+			provider := new(dataProviderMock)
+			d.MockProvider(provider)
+
+			ah := &handlers.ArticleHandler{Config: &config.Config{TagSuggestionLimit: 5}, Provider: provider}
+			d.Handler(ah)(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, http.StatusOK, w.Code, "status")
+			assert.Equal(t, "text/csv", w.Header().Get("Content-Type"), "content type")
+			assert.Equal(t, d.ExpectedBody, w.Body.String(), "body")
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eve-qunliu/articles/bulk"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func (ah *ArticleHandler) ExportArticles() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := models.ExportFilter{From: query.Get("from"), Tag: query.Get("tag"), To: query.Get("to")}
+
+		if err := filter.Normalize(ah.Config.Tags); err != nil {
+			sendResponse(w, r, &response{Status: http.StatusBadRequest, err: err})
+			return
+		}
+
+		compress := query.Get("gzip") == "true" || acceptsGzip(r.Header.Get("Accept-Encoding"))
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Vary", "Accept-Encoding")
+		if compress {
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+
+		if err := bulk.Export(w, ah.Provider, filter, compress); err != nil {
+			logger.Errorf("failed to export articles: %s", err)
+		}
+	}
+}
+
+func acceptsGzip(header string) bool {
+	wildcard := false
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		quality := 1.0
+
+		for _, param := range params[1:] {
+			param = strings.ToLower(strings.TrimSpace(param))
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				q = 0
+			}
+
+			quality = q
+		}
+
+		switch strings.ToLower(strings.TrimSpace(params[0])) {
+		case "gzip", "x-gzip":
+			return quality > 0
+		case "*":
+			wildcard = quality > 0
+		}
+	}
+
+	return wildcard
+}
@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestExportArticlesCompression(t *testing.T) {
+	data := []struct {
+		Name           string
+		Query          string
+		AcceptEncoding string
+		ExpectedGzip   bool
+	}{
+		{Name: "No Accept-Encoding"},
+		{Name: "gzip accepted", AcceptEncoding: "deflate, gzip;q=0.5", ExpectedGzip: true},
+		{Name: "gzip refused", AcceptEncoding: "gzip;q=0, deflate"},
+		{Name: "Wildcard accepted", AcceptEncoding: "*", ExpectedGzip: true},
+		{Name: "Wildcard without gzip", AcceptEncoding: "gzip;q=0, *"},
+		{Name: "gzip asked for", Query: "?gzip=true", ExpectedGzip: true},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/export"+d.Query, nil)
+			require.NoError(t, err, "failed to create request")
+			if d.AcceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", d.AcceptEncoding)
+			}
+
+			provider := new(dataProviderMock)
+			provider.On("ExportArticles", models.ExportFilter{}).Return([]*models.Article{}, nil)
+
+			ah := handlers.ArticleHandler{Config: &config.Config{}, Provider: provider}
+			ah.ExportArticles()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, http.StatusOK, w.Code, "status")
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), "Vary header")
+			if d.ExpectedGzip {
+				assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), "Content-Encoding header")
+			} else {
+				assert.Empty(t, w.Header().Get("Content-Encoding"), "Content-Encoding header")
+			}
+		})
+	}
+}
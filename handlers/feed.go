@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+const (
+	RSS  = "rss"
+	Atom = "atom"
+)
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Content    atomContent    `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func (ah *ArticleHandler) Feed(format string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		tag, err := ah.Config.Tags.Normalize(models.Tag(mux.Vars(r)["tagName"]))
+
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		articles, err := ah.Provider.LatestArticles(string(tag), ah.Config.FeedLimit)
+
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		var lastModified time.Time
+		for _, article := range articles {
+			if article.UpdatedAt.After(lastModified) {
+				lastModified = article.UpdatedAt
+			}
+		}
+
+		base := ah.publicURL(r)
+		title := "Latest articles"
+		if tag != "" {
+			title = fmt.Sprintf("Articles tagged %s", tag)
+		}
+
+		var feed interface{}
+		contentType := "application/rss+xml; charset=utf-8"
+		if format == Atom {
+			feed = newAtomFeed(base, base+r.URL.Path, title, lastModified, articles)
+			contentType = "application/atom+xml; charset=utf-8"
+		} else {
+			feed = newRSS(base, title, lastModified, articles)
+		}
+
+		payload, err := encodeXML(feed)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		resp.Status = http.StatusOK
+		resp.Payload = payload
+		resp.header().Set("Content-Type", contentType)
+		resp.cacheable = true
+	}
+}
+
+func newRSS(base string, title string, lastModified time.Time, articles []*models.Article) *rss {
+	feed := &rss{
+		Version: "2.0",
+		Channel: rssChannel{Title: title, Link: base, Description: title},
+	}
+
+	if !lastModified.IsZero() {
+		feed.Channel.LastBuildDate = lastModified.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, article := range articles {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       article.Title,
+			Link:        articleURL(base, article),
+			Description: article.HTML(),
+			GUID:        rssGUID{Value: articleGUID(base, article)},
+			PubDate:     published(article).Format(time.RFC1123Z),
+			Categories:  tagNames(article),
+		})
+	}
+
+	return feed
+}
+
+func newAtomFeed(base string, self string, title string, lastModified time.Time, articles []*models.Article) *atomFeed {
+	feed := &atomFeed{
+		ID:      self,
+		Title:   title,
+		Updated: lastModified.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: self, Rel: "self"}, {Href: base, Rel: "alternate"}},
+	}
+
+	for _, article := range articles {
+		entry := atomEntry{
+			ID:        articleGUID(base, article),
+			Title:     article.Title,
+			Published: published(article).Format(time.RFC3339),
+			Updated:   article.UpdatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: articleURL(base, article), Rel: "alternate"},
+			Content:   atomContent{Type: "html", Value: article.HTML()},
+		}
+
+		for _, name := range tagNames(article) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: name})
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+func (ah *ArticleHandler) publicURL(r *http.Request) string {
+	if ah.Config.PublicBaseURL != "" {
+		return strings.TrimRight(ah.Config.PublicBaseURL, "/")
+	}
+
+	return baseURL(r)
+}
+
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+func articleURL(base string, article *models.Article) string {
+	return slugURL(base, article.Slug)
+}
+
+func slugURL(base string, slug string) string {
+	return fmt.Sprintf("%s/articles/by-slug/%s", base, url.PathEscape(slug))
+}
+
+// articleGUID is an RFC 4151 tag URI, which stays the same when the article is edited.
+func articleGUID(base string, article *models.Article) string {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = u.Hostname()
+	}
+
+	return fmt.Sprintf("tag:%s,%s:/articles/%d", host, article.CreatedAt.UTC().Format("2006-01-02"), article.ID)
+}
+
+func published(article *models.Article) time.Time {
+	created := article.CreatedAt.UTC()
+
+	date, err := time.Parse("2006-01-02", article.Date)
+	if err != nil || created.Format("2006-01-02") == article.Date {
+		return created
+	}
+
+	return date
+}
+
+func tagNames(article *models.Article) []string {
+	names := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		names = append(names, string(tag))
+	}
+
+	return names
+}
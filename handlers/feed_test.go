@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestFeed(t *testing.T) {
+	createdAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2018, 6, 13, 8, 30, 0, 0, time.UTC)
+	articles := []*models.Article{
+		{Body: "z3 & more", CreatedAt: createdAt, Date: "2018-06-12", ID: 2, Slug: "z2", Tags: []models.Tag{"sports", "music"}, Title: "z2", UpdatedAt: updatedAt},
+		{Body: "z3", CreatedAt: createdAt, Date: "2018-06-01", ID: 1, Slug: "z1", Tags: []models.Tag{"sports"}, Title: "z1", UpdatedAt: createdAt},
+	}
+
+	data := []struct {
+		Name                string
+		Format              string
+		Tag                 string
+		Headers             map[string]string
+		Articles            []*models.Article
+		Err                 error
+		ExpectedStatus      int
+		ExpectedContentType string
+		ExpectedBody        []string
+	}{
+		{
+			Name:           "Failure - query error",
+			Format:         handlers.RSS,
+			Tag:            "sports",
+			Err:            errors.New("unknown error"),
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:                "Success - RSS feed of a tag",
+			Format:              handlers.RSS,
+			Tag:                 "sports",
+			Articles:            articles,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/rss+xml; charset=utf-8",
+			ExpectedBody: []string{
+				`<rss version="2.0"><channel><title>Articles tagged sports</title><link>http://example.com</link>`,
+				`<lastBuildDate>Wed, 13 Jun 2018 08:30:00 +0000</lastBuildDate>`,
+				`<item><title>z2</title><link>http://example.com/articles/by-slug/z2</link><description>&lt;p&gt;z3 &amp;amp; more&lt;/p&gt;</description>` +
+					`<guid isPermaLink="false">tag:example.com,2018-06-12:/articles/2</guid>` +
+					`<pubDate>Tue, 12 Jun 2018 10:00:00 +0000</pubDate><category>sports</category><category>music</category></item>`,
+				`<pubDate>Fri, 01 Jun 2018 00:00:00 +0000</pubDate>`,
+			},
+		},
+		{
+			Name:                "Success - site wide Atom feed",
+			Format:              handlers.Atom,
+			Articles:            articles,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/atom+xml; charset=utf-8",
+			ExpectedBody: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom"><id>http://example.com/feed.atom</id><title>Latest articles</title>`,
+				`<updated>2018-06-13T08:30:00Z</updated><link href="http://example.com/feed.atom" rel="self"></link>`,
+				`<entry><id>tag:example.com,2018-06-12:/articles/2</id><title>z2</title>` +
+					`<published>2018-06-12T10:00:00Z</published><updated>2018-06-13T08:30:00Z</updated>` +
+					`<link href="http://example.com/articles/by-slug/z2" rel="alternate"></link><content type="html">&lt;p&gt;z3 &amp;amp; more&lt;/p&gt;</content>` +
+					`<category term="sports"></category><category term="music"></category></entry>`,
+			},
+		},
+		{
+			Name:                "Success - If-Modified-Since ignored",
+			Format:              handlers.RSS,
+			Tag:                 "sports",
+			Headers:             map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			Articles:            articles,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/rss+xml; charset=utf-8",
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "http://example.com/feed."+d.Format, nil)
+			require.NoError(t, err, "failed to create request")
+			if d.Tag != "" {
+				r = mux.SetURLVars(r, map[string]string{"tagName": d.Tag})
+			}
+			for key, value := range d.Headers {
+				r.Header.Set(key, value)
+			}
+
+			provider := new(dataProviderMock)
+			provider.OnLatestArticles(d.Tag, 5).Return(d.Articles, d.Err)
+
+			ah := handlers.ArticleHandler{Config: &config.Config{FeedLimit: 5}, Provider: provider}
+			ah.Feed(d.Format)(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			if d.ExpectedContentType != "" {
+				assert.Equal(t, d.ExpectedContentType, w.Header().Get("Content-Type"), "content type")
+				assert.NotEmpty(t, w.Header().Get("ETag"), "ETag header")
+			}
+			for _, fragment := range d.ExpectedBody {
+				assert.Contains(t, w.Body.String(), fragment, "body")
+			}
+		})
+	}
+}
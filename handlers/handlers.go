@@ -22,16 +22,82 @@ func init() {
 	defer logger.Sync()
 }
 
-func NewHandler(config *config.Config, provider providers.DataProvider) *mux.Router {
+func NewHandler(config *config.Config, provider providers.Provider) *mux.Router {
 	router := mux.NewRouter()
 	article := &ArticleHandler{Config: config, Provider: provider}
+	idempotency := &IdempotencyHandler{Config: config, Store: provider}
+	tags := &TagHandler{Config: config, Admin: provider}
 
-	router.HandleFunc("/articles", article.CreateArticles()).
+	validator, err := newValidator(openAPISpec, config.MaxBodyBytes, config.MaxStreamBodyBytes)
+	if err != nil {
+		logger.Fatalf("can't load the OpenAPI document: %v", err)
+	}
+
+	router.Use(timeout(config.RequestTimeout))
+	router.Use(validator.Middleware)
+
+	router.HandleFunc("/articles", idempotency.Wrap(article.CreateArticles())).
 		Methods("POST")
+	router.HandleFunc("/articles/batch", article.ImportArticles()).
+		Methods("POST").Name("importArticles")
+	router.HandleFunc("/articles/by-slug/{slug}", article.FindArticleBySlug()).
+		Methods("GET")
 	router.HandleFunc("/articles/{id}", article.FindArticle()).
 		Methods("GET")
+	router.HandleFunc("/articles/{id}", article.UpdateArticle()).
+		Methods("PUT")
+	router.HandleFunc("/articles/{id}", article.DeleteArticle()).
+		Methods("DELETE")
+	router.HandleFunc("/articles/{id}/revisions", article.ArticleRevisions()).
+		Methods("GET")
+	router.HandleFunc("/articles/{id}/revisions/{revision}", article.ArticleRevision()).
+		Methods("GET")
+	router.HandleFunc("/articles/{id}/revisions/{revision}/restore", article.RestoreRevision()).
+		Methods("POST")
+	router.HandleFunc("/articles/{id}/diff", article.DiffRevisions()).
+		Methods("GET")
+	router.HandleFunc("/tags", article.SuggestTags()).
+		Methods("GET")
+	router.HandleFunc("/tag/{tagName}/feed.rss", article.Feed(RSS)).
+		Methods("GET")
+	router.HandleFunc("/tag/{tagName}/feed.atom", article.Feed(Atom)).
+		Methods("GET")
 	router.HandleFunc("/tag/{tagName}/{date}", article.FindTag()).
 		Methods("GET")
+	router.HandleFunc("/feed.rss", article.Feed(RSS)).
+		Methods("GET")
+	router.HandleFunc("/feed.atom", article.Feed(Atom)).
+		Methods("GET")
+	router.HandleFunc("/sitemap.xml", article.SitemapIndex()).
+		Methods("GET")
+	router.HandleFunc("/sitemaps/{page}.xml", article.Sitemap()).
+		Methods("GET")
+	router.HandleFunc("/export", AdminOnly(config.AdminToken, article.ExportArticles())).
+		Methods("GET").Name("exportArticles")
+	router.HandleFunc("/admin/articles/{id}/restore", AdminOnly(config.AdminToken, article.RestoreArticle())).
+		Methods("POST")
+	router.HandleFunc("/admin/tags/audit-log", AdminOnly(config.AdminToken, tags.TagAuditLog())).
+		Methods("GET")
+	router.HandleFunc("/admin/tags/tree", AdminOnly(config.AdminToken, tags.TagTree())).
+		Methods("GET")
+	router.HandleFunc("/admin/tags/{tagName}/parent", AdminOnly(config.AdminToken, tags.SetTagParent())).
+		Methods("PUT")
+	router.HandleFunc("/admin/tags/{tagName}/parent", AdminOnly(config.AdminToken, tags.RemoveTagParent())).
+		Methods("DELETE")
+	router.HandleFunc("/admin/tags/{tagName}/rename", AdminOnly(config.AdminToken, tags.RenameTag())).
+		Methods("POST")
+	router.HandleFunc("/admin/tags/{tagName}/merge", AdminOnly(config.AdminToken, tags.MergeTags())).
+		Methods("POST")
+	router.HandleFunc("/admin/tag-aliases", AdminOnly(config.AdminToken, tags.TagAliases())).
+		Methods("GET")
+	router.HandleFunc("/admin/tag-aliases/{alias}", AdminOnly(config.AdminToken, tags.TagAlias())).
+		Methods("GET")
+	router.HandleFunc("/admin/tag-aliases/{alias}", AdminOnly(config.AdminToken, tags.SaveTagAlias())).
+		Methods("PUT")
+	router.HandleFunc("/admin/tag-aliases/{alias}", AdminOnly(config.AdminToken, tags.DeleteTagAlias())).
+		Methods("DELETE")
+	router.HandleFunc("/openapi.json", OpenAPI()).
+		Methods("GET")
 
 	return router
 }
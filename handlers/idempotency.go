@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyHandler replays the first successful response for an
+// Idempotency-Key; failed requests release the key.
+type IdempotencyHandler struct {
+	Config *config.Config
+	Store  providers.IdempotencyStore
+}
+
+func (ih *IdempotencyHandler) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		if len(key) > 255 {
+			resp.Status = http.StatusBadRequest
+			resp.err = errors.New("idempotency key is longer than 255 characters")
+			sendResponse(w, r, resp)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			sendResponse(w, r, resp)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		record := &models.IdempotencyKey{Key: key, RequestHash: requestHash(r, body)}
+
+		reserved, err := ih.Store.ReserveIdempotencyKey(record, time.Now().Add(-ih.Config.IdempotencyTTL))
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			sendResponse(w, r, resp)
+			return
+		}
+
+		if !reserved {
+			ih.replay(w, r, record)
+			return
+		}
+
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+
+			if err := ih.Store.DeleteIdempotencyKey(key); err != nil {
+				logger.Errorf("failed to release idempotency key %q: %s", key, err)
+			}
+		}()
+
+		capture := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+		next(capture, r)
+
+		if capture.status < http.StatusOK || capture.status >= http.StatusMultipleChoices {
+			return
+		}
+
+		record.Status = capture.status
+		record.Response = capture.body.Bytes()
+		record.Headers = make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := capture.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+
+		if err = ih.Store.SaveIdempotencyKey(record); err != nil {
+			logger.Errorf("failed to record idempotency key %q: %s", key, err)
+			return
+		}
+
+		saved = true
+	}
+}
+
+func (ih *IdempotencyHandler) replay(w http.ResponseWriter, r *http.Request, record *models.IdempotencyKey) {
+	resp := &response{
+		Status: http.StatusOK,
+	}
+
+	stored, err := ih.Store.FindIdempotencyKey(record.Key)
+
+	switch {
+	case err != nil:
+		resp.Status = http.StatusInternalServerError
+		resp.err = err
+	case stored == nil || stored.Status == 0:
+		resp.Status = http.StatusConflict
+		resp.err = errors.New("a request with this idempotency key is in progress")
+	case stored.RequestHash != record.RequestHash:
+		resp.Status = http.StatusUnprocessableEntity
+		resp.err = errors.New("idempotency key was used with a different request")
+	default:
+		resp.Status = stored.Status
+		resp.Payload = stored.Response
+		for name, value := range stored.Headers {
+			resp.header().Set(name, value)
+		}
+		resp.header().Set("Idempotent-Replayed", "true")
+	}
+
+	sendResponse(w, r, resp)
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	// Replays are sent as stored, so they must have been negotiated alike.
+	if accept := r.Header.Get("Accept"); accept != "" {
+		hash.Write([]byte("Accept: " + accept + "\n"))
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rc *responseCapture) WriteHeader(status int) {
+	rc.status = status
+	rc.ResponseWriter.WriteHeader(status)
+}
+
+func (rc *responseCapture) Write(payload []byte) (int, error) {
+	rc.body.Write(payload)
+	return rc.ResponseWriter.Write(payload)
+}
@@ -0,0 +1,199 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+type idempotencyStoreMock struct {
+	mock.Mock
+}
+
+func (m *idempotencyStoreMock) DeleteIdempotencyKey(key string) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *idempotencyStoreMock) FindIdempotencyKey(key string) (*models.IdempotencyKey, error) {
+	rtn := m.Called(key)
+	return rtn.Get(0).(*models.IdempotencyKey), rtn.Error(1)
+}
+
+func (m *idempotencyStoreMock) PurgeIdempotencyKeys(before time.Time) (int64, error) {
+	rtn := m.Called(before)
+	return rtn.Get(0).(int64), rtn.Error(1)
+}
+
+func (m *idempotencyStoreMock) ReserveIdempotencyKey(key *models.IdempotencyKey, expiredBefore time.Time) (bool, error) {
+	rtn := m.Called(key.Key, key.RequestHash)
+	return rtn.Bool(0), rtn.Error(1)
+}
+
+func (m *idempotencyStoreMock) SaveIdempotencyKey(key *models.IdempotencyKey) error {
+	return m.Called(key.Key, key.Status, string(key.Response), key.Headers).Error(0)
+}
+
+func TestIdempotency(t *testing.T) {
+	// sha256 of "POST /articles\n" followed by the request body below.
+	hash := "b8c4ac0532fe08c2cef60b8257742ec800a7b98c20b8d80507c59c0a64f17b4b"
+	data := []struct {
+		Name             string
+		Key              string
+		HandlerStatus    int
+		HandlerPanics    bool
+		ExpectedStatus   int
+		ExpectedBody     string
+		ExpectedLocation string
+		ExpectCalled     bool
+		MockStore        func(m *idempotencyStoreMock)
+	}{
+		{
+			Name:           "Success - no key passes through",
+			HandlerStatus:  http.StatusCreated,
+			ExpectedStatus: http.StatusCreated,
+			ExpectedBody:   "created",
+			ExpectCalled:   true,
+		},
+		{
+			Name:           "Success - first request is stored",
+			Key:            "abc",
+			HandlerStatus:  http.StatusCreated,
+			ExpectedStatus: http.StatusCreated,
+			ExpectedBody:   "created",
+			ExpectCalled:   true,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(true, nil)
+				m.On("SaveIdempotencyKey", "abc", http.StatusCreated, "created",
+					map[string]string{"Content-Type": "application/json", "Location": "/articles/1"}).Return(nil)
+			},
+		},
+		{
+			Name:           "Success - server error releases the key",
+			Key:            "abc",
+			HandlerStatus:  http.StatusInternalServerError,
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedBody:   "created",
+			ExpectCalled:   true,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(true, nil)
+				m.On("DeleteIdempotencyKey", "abc").Return(nil)
+			},
+		},
+		{
+			Name:           "Success - client error releases the key",
+			Key:            "abc",
+			HandlerStatus:  http.StatusUnprocessableEntity,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			ExpectCalled:   true,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(true, nil)
+				m.On("DeleteIdempotencyKey", "abc").Return(nil)
+			},
+		},
+		{
+			Name:          "Success - panic releases the key",
+			Key:           "abc",
+			HandlerPanics: true,
+			ExpectCalled:  true,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(true, nil)
+				m.On("DeleteIdempotencyKey", "abc").Return(nil)
+			},
+		},
+		{
+			Name:             "Success - retry replays stored response",
+			Key:              "abc",
+			ExpectedStatus:   http.StatusCreated,
+			ExpectedBody:     "stored",
+			ExpectedLocation: "/articles/1",
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(false, nil)
+				m.On("FindIdempotencyKey", "abc").Return(&models.IdempotencyKey{Key: "abc", RequestHash: hash, Status: http.StatusCreated,
+					Response: []byte("stored"), Headers: map[string]string{"Location": "/articles/1"}}, nil)
+			},
+		},
+		{
+			Name:           "Failure - key reused with a different body",
+			Key:            "abc",
+			ExpectedStatus: http.StatusUnprocessableEntity,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(false, nil)
+				m.On("FindIdempotencyKey", "abc").Return(&models.IdempotencyKey{Key: "abc", RequestHash: "other", Status: http.StatusCreated}, nil)
+			},
+		},
+		{
+			Name:           "Failure - original request in progress",
+			Key:            "abc",
+			ExpectedStatus: http.StatusConflict,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(false, nil)
+				m.On("FindIdempotencyKey", "abc").Return(&models.IdempotencyKey{Key: "abc", RequestHash: hash}, nil)
+			},
+		},
+		{
+			Name:           "Failure - store error",
+			Key:            "abc",
+			ExpectedStatus: http.StatusInternalServerError,
+			MockStore: func(m *idempotencyStoreMock) {
+				m.On("ReserveIdempotencyKey", "abc", mock.Anything).Return(false, errors.New("unknown error"))
+			},
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/articles", strings.NewReader(`{"title":"z1"}`))
+			assert.NoError(t, err, "failed to create request")
+			if d.Key != "" {
+				r.Header.Set("Idempotency-Key", d.Key)
+			}
+
+			store := new(idempotencyStoreMock)
+			if d.MockStore != nil {
+				d.MockStore(store)
+			}
+
+			called := false
+			next := func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if d.HandlerPanics {
+					panic("handler failed")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Location", "/articles/1")
+				w.WriteHeader(d.HandlerStatus)
+				w.Write([]byte("created"))
+			}
+
+			config := config.Config{IdempotencyTTL: time.Hour}
+			ih := handlers.IdempotencyHandler{Config: &config, Store: store}
+			handler := ih.Wrap(next)
+
+			if d.HandlerPanics {
+				assert.Panics(t, func() { handler(w, r) }, "panic")
+			} else {
+				handler(w, r)
+				assert.Equal(t, d.ExpectedStatus, w.Code, "expectedStatus code")
+			}
+			store.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectCalled, called, "handler called")
+			if d.ExpectedBody != "" {
+				assert.Equal(t, d.ExpectedBody, w.Body.String(), "body")
+			}
+			if d.ExpectedLocation != "" {
+				assert.Equal(t, d.ExpectedLocation, w.Header().Get("Location"), "Location header")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/eve-qunliu/articles/bulk"
+)
+
+func (ah *ArticleHandler) ImportArticles() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		importer := &bulk.Importer{Editor: r.Header.Get(editorHeader), Provider: ah.Provider, Tags: ah.Config.Tags, TagLimit: ah.Config.TagLimit}
+		report, err := importer.Import(r.Body)
+
+		// Articles read before the stream broke may already be stored.
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+		}
+
+		resp.value = report
+	}
+}
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+)
+
+func TestImportArticles(t *testing.T) {
+	valid := `{"title":"z1","body":"z3","date":"2018-06-12","tags":["sports"]}`
+	data := []struct {
+		Name           string
+		Payload        string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Success - articles imported",
+			Payload:        "[" + valid + "]",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"created":1,"failed":0`,
+		},
+		{
+			Name:           "Failure - broken stream still reports what was stored",
+			Payload:        "[" + valid + ",",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `"created":1,"failed":1`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/articles/batch", strings.NewReader(d.Payload))
+			assert.NoError(t, err, "failed to create request")
+
+			provider := new(dataProviderMock)
+			provider.On("CreateArticles", mock.Anything).Return(nil)
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+			ah.ImportArticles()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+		})
+	}
+}
+
+func TestImportArticlesBodyLimit(t *testing.T) {
+	valid := `{"title":"z1","body":"z3","date":"2018-06-12","tags":["sports"]}`
+	data := []struct {
+		Name           string
+		MaxStreamBytes int64
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Success - stream within the stream limit",
+			MaxStreamBytes: 1024,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"created":2,"failed":0`,
+		},
+		{
+			Name:           "Failure - stream over the stream limit",
+			MaxStreamBytes: int64(len(valid)) + 8,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   `"created":1,"failed":1`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/articles/batch", strings.NewReader(valid+"\n"+valid+"\n"))
+			assert.NoError(t, err, "failed to create request")
+			r.Header.Set("Content-Type", "application/x-ndjson")
+
+			provider := new(dataProviderMock)
+			provider.On("CreateArticles", mock.Anything).Return(nil).Maybe()
+
+			cfg := &config.Config{MaxBodyBytes: 16, MaxStreamBodyBytes: d.MaxStreamBytes, TagLimit: 3}
+			handlers.NewHandler(cfg, providerMock{provider, nil, nil}).ServeHTTP(w, r)
+
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+func OpenAPI() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{Status: http.StatusOK, Payload: openAPISpec}
+		resp.header().Set("Content-Type", "application/json")
+		sendResponse(w, r, resp)
+	}
+}
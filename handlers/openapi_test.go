@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+)
+
+func TestOpenAPI(t *testing.T) {
+	router := handlers.NewHandler(&config.Config{}, nil)
+
+	var routes []string
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		require.NoError(t, err, "route %s has no methods", path)
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+		return nil
+	})
+	require.NoError(t, err, "failed to walk routes")
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/openapi.json", nil)
+	require.NoError(t, err, "failed to create request")
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code, "expectedStatus code")
+
+	var spec struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec), "invalid OpenAPI document")
+	assert.True(t, strings.HasPrefix(spec.OpenAPI, "3."), "OpenAPI version")
+
+	var documented []string
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented, "documented routes")
+}
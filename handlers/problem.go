@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// problem is an RFC 7807 problem details body.
+type problem struct {
+	XMLName xml.Name       `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type    string         `json:"type" xml:"type"`
+	Title   string         `json:"title" xml:"title"`
+	Status  int            `json:"status" xml:"status"`
+	Detail  string         `json:"detail,omitempty" xml:"detail,omitempty"`
+	Errors  []invalidField `json:"errors,omitempty" xml:"errors>error,omitempty"`
+}
+
+type invalidField struct {
+	Location string `json:"location" xml:"location"`
+	Message  string `json:"message" xml:"message"`
+}
+
+// describeProblem only discloses the error of client errors.
+func describeProblem(resp *response, fields []invalidField) {
+	body := &problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(resp.Status),
+		Status: resp.Status,
+		Errors: fields,
+	}
+
+	if resp.err != nil && resp.Status < http.StatusInternalServerError {
+		body.Detail = resp.err.Error()
+	}
+
+	resp.value = body
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+const editorHeader = "X-Editor"
+
+func (ah *ArticleHandler) ArticleRevisions() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		vars := mux.Vars(r)
+		if resp.Status, resp.err = ah.publishedArticle(vars["id"]); resp.Status != http.StatusOK {
+			return
+		}
+
+		revisions, err := ah.Provider.ArticleRevisions(vars["id"])
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		if len(revisions) == 0 {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		resp.value = &models.Revisions{Revisions: revisions}
+	}
+}
+
+func (ah *ArticleHandler) ArticleRevision() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		vars := mux.Vars(r)
+		if resp.Status, resp.err = ah.publishedArticle(vars["id"]); resp.Status != http.StatusOK {
+			return
+		}
+
+		revision, status, err := ah.findRevision(vars["id"], vars["revision"])
+		if revision == nil {
+			resp.Status = status
+			resp.err = err
+			return
+		}
+
+		resp.value = revision
+	}
+}
+
+func (ah *ArticleHandler) DiffRevisions() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		vars := mux.Vars(r)
+		if resp.Status, resp.err = ah.publishedArticle(vars["id"]); resp.Status != http.StatusOK {
+			return
+		}
+
+		query := r.URL.Query()
+
+		from, status, err := ah.findRevision(vars["id"], query.Get("from"))
+		if from == nil {
+			resp.Status = status
+			resp.err = err
+			return
+		}
+
+		to, status, err := ah.findRevision(vars["id"], query.Get("to"))
+		if to == nil {
+			resp.Status = status
+			resp.err = err
+			return
+		}
+
+		resp.value = models.Diff(from, to)
+	}
+}
+
+// RestoreRevision is conditioned on the version the client read, like any other write.
+func (ah *ArticleHandler) RestoreRevision() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		version, err := queryVersion(r)
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		vars := mux.Vars(r)
+		revision, status, err := ah.findRevision(vars["id"], vars["revision"])
+		if revision == nil {
+			resp.Status = status
+			resp.err = err
+			return
+		}
+
+		article, err := ah.Provider.FindArticle(vars["id"])
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		if article == nil {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		article.Restore(revision)
+		article.Editor = r.Header.Get(editorHeader)
+
+		if err = article.Normalize(ah.Config.TagLimit, ah.Config.Tags); err != nil {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = err
+			return
+		}
+
+		article.Version, resp.Status, resp.err = ah.expectedVersion(r, vars["id"], version)
+
+		if resp.Status != http.StatusOK {
+			return
+		}
+
+		if err = ah.Provider.UpdateArticle(article); err != nil {
+			resp.Status = writeErrorStatus(r, err)
+			resp.err = err
+			return
+		}
+
+		resp.value = article
+	}
+}
+
+// publishedArticle hides the revisions of articles readers cannot see.
+func (ah *ArticleHandler) publishedArticle(id string) (int, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return http.StatusBadRequest, errors.Errorf("article id %q must be numeric", id)
+	}
+
+	article, err := ah.Provider.FindArticle(id)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if article == nil || !article.Published() {
+		return http.StatusNotFound, nil
+	}
+
+	return http.StatusOK, nil
+}
+
+func (ah *ArticleHandler) findRevision(id string, number string) (*models.Revision, int, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, http.StatusBadRequest, errors.Errorf("article id %q must be numeric", id)
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return nil, http.StatusBadRequest, errors.Errorf("revision %q must be a positive number", number)
+	}
+
+	revision, err := ah.Provider.ArticleRevision(id, n)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if revision == nil {
+		return nil, http.StatusNotFound, nil
+	}
+
+	return revision, http.StatusOK, nil
+}
@@ -0,0 +1,257 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+func TestArticleRevisions(t *testing.T) {
+	revisions := []models.Revision{
+		{ArticleID: 123, Body: "z3", Date: "2018-06-12", Number: 1, Tags: []models.Tag{"sports"}, Title: "z1"},
+		{ArticleID: 123, Body: "z4", Date: "2018-06-12", Editor: "eve", Number: 2, Tags: []models.Tag{"sports"}, Title: "z1"},
+	}
+
+	data := []struct {
+		Name           string
+		ID             string
+		MockProvider   func(m *dataProviderMock)
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Failure - invalid id",
+			ID:             "abc",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name: "Failure - article not published",
+			ID:   "123",
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123, Status: models.StatusDraft}, nil)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name: "Failure - query error",
+			ID:   "123",
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevisions", "123").Return([]models.Revision(nil), errors.New("unknown error"))
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name: "Failure - article not exist",
+			ID:   "123",
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevisions", "123").Return([]models.Revision{}, nil)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name: "Success - revisions found",
+			ID:   "123",
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevisions", "123").Return(revisions, nil)
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"editor":"eve","format":"","number":2`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/articles/"+d.ID+"/revisions", nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"id": d.ID})
+
+			provider := new(dataProviderMock)
+			if d.MockProvider != nil {
+				d.MockProvider(provider)
+			}
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+			ah.ArticleRevisions()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+		})
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	first := models.Revision{ArticleID: 123, Body: "a\nb\n", Date: "2018-06-12", Number: 1, Tags: []models.Tag{"sports"}, Title: "z1"}
+	second := models.Revision{ArticleID: 123, Body: "a\nc\n", Date: "2018-06-12", Number: 2, Tags: []models.Tag{"music"}, Title: "z2"}
+
+	data := []struct {
+		Name           string
+		Query          string
+		MockProvider   func(m *dataProviderMock)
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Failure - article not published",
+			Query:          "?from=1&to=2",
+			ExpectedStatus: http.StatusNotFound,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123, Status: models.StatusScheduled}, nil)
+			},
+		},
+		{
+			Name:           "Failure - missing revision",
+			Query:          "?from=1",
+			ExpectedStatus: http.StatusBadRequest,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevision", "123", 1).Return(&first, nil)
+			},
+		},
+		{
+			Name:           "Failure - revision not exist",
+			Query:          "?from=1&to=3",
+			ExpectedStatus: http.StatusNotFound,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevision", "123", 1).Return(&first, nil)
+				m.On("ArticleRevision", "123", 3).Return((*models.Revision)(nil), nil)
+			},
+		},
+		{
+			Name:           "Success - revisions compared",
+			Query:          "?from=1&to=2",
+			ExpectedStatus: http.StatusOK,
+			MockProvider: func(m *dataProviderMock) {
+				m.OnFindArticle("123").Return(&models.Article{ID: 123}, nil)
+				m.On("ArticleRevision", "123", 1).Return(&first, nil)
+				m.On("ArticleRevision", "123", 2).Return(&second, nil)
+			},
+			ExpectedBody: `"title":{"from":"z1","to":"z2"},"tags_added":["music"],"tags_removed":["sports"],"body":[" a","-b","+c"]`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/articles/123/diff"+d.Query, nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+
+			provider := new(dataProviderMock)
+			if d.MockProvider != nil {
+				d.MockProvider(provider)
+			}
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+			ah.DiffRevisions()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+		})
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	revision := models.Revision{ArticleID: 123, Body: "z3", Date: "2018-06-12", Number: 1, Tags: []models.Tag{"sports"}, Title: "z1"}
+	current := func() *models.Article {
+		return &models.Article{Body: "z4", Date: "2018-06-13", ID: 123, Tags: []models.Tag{"music"}, Title: "z2", Version: 2}
+	}
+	restored := models.Article{Body: "z3", Date: "2018-06-12", Editor: "eve", ID: 123, Tags: []models.Tag{"sports"}, Title: "z1", Version: 2}
+	stale := restored
+	stale.Version = 1
+
+	data := []struct {
+		Name           string
+		Revision       string
+		Query          string
+		MockProvider   func(m *dataProviderMock)
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - invalid revision",
+			Revision:       "0",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:     "Failure - revision not exist",
+			Revision: "4",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("ArticleRevision", "123", 4).Return((*models.Revision)(nil), nil)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Failure - invalid version",
+			Revision:       "1",
+			Query:          "?version=abc",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:     "Failure - version required",
+			Revision: "1",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("ArticleRevision", "123", 1).Return(&revision, nil)
+				m.OnFindArticle("123").Return(current(), nil)
+			},
+			ExpectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			Name:     "Failure - stale version",
+			Revision: "1",
+			Query:    "?version=1",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("ArticleRevision", "123", 1).Return(&revision, nil)
+				m.OnFindArticle("123").Return(current(), nil)
+				m.OnUpdateArticle(&stale).Return(providers.ErrConflict)
+			},
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:     "Success - revision restored",
+			Revision: "1",
+			Query:    "?version=2",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("ArticleRevision", "123", 1).Return(&revision, nil)
+				m.OnFindArticle("123").Return(current(), nil)
+				m.OnUpdateArticle(&restored).Return(nil)
+			},
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/articles/123/revisions/"+d.Revision+"/restore"+d.Query, nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"id": "123", "revision": d.Revision})
+			r.Header.Set("X-Editor", "eve")
+
+			provider := new(dataProviderMock)
+			if d.MockProvider != nil {
+				d.MockProvider(provider)
+			}
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagLimit: 3}, Provider: provider}
+			ah.RestoreRevision()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+		})
+	}
+}
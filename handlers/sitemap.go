@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+const sitemapPageSize = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func (ah *ArticleHandler) SitemapIndex() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		pages, err := ah.Provider.SitemapPages(sitemapPageSize)
+
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		base := ah.publicURL(r)
+		index := &sitemapIndex{}
+		var lastModified time.Time
+
+		for _, page := range pages {
+			index.Sitemaps = append(index.Sitemaps, sitemapURL{
+				Loc:     fmt.Sprintf("%s/sitemaps/%d.xml", base, page.Page),
+				LastMod: page.LastModified.UTC().Format(time.RFC3339),
+			})
+
+			if page.LastModified.After(lastModified) {
+				lastModified = page.LastModified
+			}
+		}
+
+		payload, err := encodeXML(index)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		resp.Status = http.StatusOK
+		resp.Payload = payload
+		resp.header().Set("Content-Type", "application/xml; charset=utf-8")
+		resp.cacheable = true
+	}
+}
+
+func (ah *ArticleHandler) Sitemap() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(mux.Vars(r)["page"])
+		if err != nil || page < 1 {
+			sendResponse(w, r, &response{Status: http.StatusBadRequest, err: errors.New("sitemap page must be a positive number")})
+			return
+		}
+
+		base := ah.publicURL(r)
+		var encoder *xml.Encoder
+
+		err = ah.Provider.SitemapEntries(page, sitemapPageSize, func(entry models.SitemapEntry) error {
+			if encoder == nil {
+				encoder = startURLSet(w)
+			}
+
+			url := sitemapURL{
+				Loc:     slugURL(base, entry.Slug),
+				LastMod: entry.UpdatedAt.UTC().Format(time.RFC3339),
+			}
+			return encoder.EncodeElement(url, xml.StartElement{Name: xml.Name{Local: "url"}})
+		})
+
+		if err != nil && encoder == nil {
+			sendResponse(w, r, &response{Status: http.StatusInternalServerError, err: err})
+			return
+		}
+
+		if err != nil {
+			logger.Errorf("failed to stream sitemap %d: %s", page, err)
+			return
+		}
+
+		if encoder == nil {
+			if page > 1 {
+				sendResponse(w, r, &response{Status: http.StatusNotFound, err: errors.Errorf("sitemap %d does not exist", page)})
+				return
+			}
+			encoder = startURLSet(w)
+		}
+
+		encoder.EncodeToken(xml.EndElement{Name: xml.Name{Space: sitemapNamespace, Local: "urlset"}})
+		if err = encoder.Flush(); err != nil {
+			logger.Errorf("failed to stream sitemap %d: %s", page, err)
+		}
+	}
+}
+
+func startURLSet(w http.ResponseWriter) *xml.Encoder {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	io.WriteString(w, xml.Header)
+
+	encoder := xml.NewEncoder(w)
+	encoder.EncodeToken(xml.StartElement{Name: xml.Name{Space: sitemapNamespace, Local: "urlset"}})
+	return encoder
+}
@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestSitemapIndex(t *testing.T) {
+	modified := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "http://internal:8080/sitemap.xml", nil)
+	require.NoError(t, err, "failed to create request")
+
+	provider := new(dataProviderMock)
+	provider.On("SitemapPages", 50000).Return([]models.SitemapPage{{LastModified: modified, Page: 1}, {LastModified: modified, Page: 2}}, nil)
+
+	ah := handlers.ArticleHandler{Config: &config.Config{PublicBaseURL: "https://example.com/"}, Provider: provider}
+	ah.SitemapIndex()(w, r)
+
+	provider.Mock.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code, "status")
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"), "content type")
+	assert.NotEmpty(t, w.Header().Get("ETag"), "ETag header")
+	assert.Empty(t, w.Header().Get("Last-Modified"), "Last-Modified header")
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+
+		`<sitemap><loc>https://example.com/sitemaps/1.xml</loc><lastmod>2018-06-12T10:00:00Z</lastmod></sitemap>`+
+		`<sitemap><loc>https://example.com/sitemaps/2.xml</loc><lastmod>2018-06-12T10:00:00Z</lastmod></sitemap>`+
+		`</sitemapindex>`, w.Body.String(), "body")
+}
+
+func TestSitemap(t *testing.T) {
+	modified := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	data := []struct {
+		Name           string
+		Page           string
+		Entries        []models.SitemapEntry
+		Err            error
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Failure - invalid page",
+			Page:           "0",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Failure - query error",
+			Page:           "1",
+			Entries:        []models.SitemapEntry{},
+			Err:            errors.New("unknown error"),
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:           "Failure - page past the last article",
+			Page:           "3",
+			Entries:        []models.SitemapEntry{},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Success - empty first page",
+			Page:           "1",
+			Entries:        []models.SitemapEntry{},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`,
+		},
+		{
+			Name:           "Success - article URLs",
+			Page:           "2",
+			Entries:        []models.SitemapEntry{{ID: 7, Slug: "z7", UpdatedAt: modified}, {ID: 9, Slug: "z9", UpdatedAt: modified}},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+				`<url><loc>http://example.com/articles/by-slug/z7</loc><lastmod>2018-06-12T10:00:00Z</lastmod></url>` +
+				`<url><loc>http://example.com/articles/by-slug/z9</loc><lastmod>2018-06-12T10:00:00Z</lastmod></url>` +
+				`</urlset>`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "http://example.com/sitemaps/"+d.Page+".xml", nil)
+			require.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"page": d.Page})
+
+			provider := new(dataProviderMock)
+			if d.Entries != nil {
+				page, _ := strconv.Atoi(d.Page)
+				provider.On("SitemapEntries", page, 50000).Return(d.Entries, d.Err)
+			}
+
+			ah := handlers.ArticleHandler{Config: &config.Config{}, Provider: provider}
+			ah.Sitemap()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			if d.ExpectedBody != "" {
+				assert.Equal(t, d.ExpectedBody, w.Body.String(), "body")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func (ah *ArticleHandler) SuggestTags() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		query := r.URL.Query()
+		if query.Get("prefix") == "" {
+			resp.Status = http.StatusBadRequest
+			resp.err = errors.New("prefix cannot be empty")
+			return
+		}
+
+		// A prefix the pipeline rejects starts no tag either.
+		prefix, err := ah.Config.Tags.Normalize(models.Tag(query.Get("prefix")))
+		if err != nil || prefix == "" {
+			resp.value = &models.TagSuggestions{Tags: []models.TagSuggestion{}}
+			return
+		}
+
+		suggestions, err := ah.Provider.SuggestTags(string(prefix), query.Get("since"), ah.Config.TagSuggestionLimit)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		resp.value = &models.TagSuggestions{Tags: suggestions}
+	}
+}
@@ -0,0 +1,77 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestSuggestTags(t *testing.T) {
+	tags, err := models.NewTagPipeline([]string{"trim", "casefold", "hyphenate", "banned"}, models.TagRules{BannedWords: []string{"spam"}})
+	assert.NoError(t, err, "pipeline")
+
+	data := []struct {
+		Name           string
+		Query          string
+		MockProvider   func(m *dataProviderMock)
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Failure - missing prefix",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:  "Failure - query error",
+			Query: "?prefix=foo",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("SuggestTags", "foo", "", 5).Return([]models.TagSuggestion(nil), errors.New("unknown error"))
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:           "Success - rejected prefix suggests nothing",
+			Query:          "?prefix=spam",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"tags":[]}`,
+		},
+		{
+			Name:  "Success - prefix normalized",
+			Query: "?prefix=New%20Yo&since=2018-06-01",
+			MockProvider: func(m *dataProviderMock) {
+				m.On("SuggestTags", "new-yo", "2018-06-01", 5).Return([]models.TagSuggestion{{Count: 4, Name: "new-york"}}, nil)
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"tags":[{"count":4,"name":"new-york"}]}`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/tags"+d.Query, nil)
+			assert.NoError(t, err, "failed to create request")
+
+			provider := new(dataProviderMock)
+			if d.MockProvider != nil {
+				d.MockProvider(provider)
+			}
+
+			ah := handlers.ArticleHandler{Config: &config.Config{TagSuggestionLimit: 5, Tags: tags}, Provider: provider}
+			ah.SuggestTags()(w, r)
+
+			provider.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			if d.ExpectedBody != "" {
+				assert.JSONEq(t, d.ExpectedBody, w.Body.String(), "body")
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+const (
+	defaultTagAuditLogLimit = 50
+	maxTagAuditLogLimit     = 500
+)
+
+type TagHandler struct {
+	Config *config.Config
+	Admin  providers.TagAdmin
+}
+
+type tagTarget struct {
+	Into string `json:"into"`
+	To   string `json:"to"`
+}
+
+func (th *TagHandler) RenameTag() func(http.ResponseWriter, *http.Request) {
+	return th.change(func(target *tagTarget) string { return target.To }, func(from, to, actor string) error {
+		return th.Admin.RenameTag(from, to, actor)
+	})
+}
+
+func (th *TagHandler) MergeTags() func(http.ResponseWriter, *http.Request) {
+	return th.change(func(target *tagTarget) string { return target.Into }, func(from, into, actor string) error {
+		return th.Admin.MergeTags(from, into, actor)
+	})
+}
+
+func (th *TagHandler) change(name func(*tagTarget) string, fn func(string, string, string) error) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusNoContent,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		target := &tagTarget{}
+		if err = json.Unmarshal(body, target); err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		from, err := th.Config.Tags.NormalizeName(mux.Vars(r)["tagName"])
+		if err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		to, err := th.Config.Tags.NormalizeName(name(target))
+		if err != nil {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = err
+			return
+		}
+
+		if to == "" {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = errors.New("target tag is empty")
+			return
+		}
+
+		actor := r.Header.Get(editorHeader)
+		if actor == "" {
+			actor = "admin"
+		}
+
+		err = fn(string(from), string(to), actor)
+
+		switch errors.Cause(err) {
+		case nil:
+		case providers.ErrNotFound:
+			resp.Status = http.StatusNotFound
+		case providers.ErrConflict:
+			resp.Status = http.StatusConflict
+			resp.err = errors.New("a tag with that name already exists, merge the tags instead")
+		default:
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+		}
+	}
+}
+
+func (th *TagHandler) TagTree() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		tags, err := th.Admin.TagTree()
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		resp.value = &models.TagTree{Tags: tags}
+	}
+}
+
+func (th *TagHandler) SetTagParent() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusNoContent,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		target := &struct {
+			Parent string `json:"parent"`
+		}{}
+		if err = json.Unmarshal(body, target); err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		parent, err := th.Config.Tags.NormalizeName(target.Parent)
+		if err != nil {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = err
+			return
+		}
+
+		if parent == "" {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = errors.New("parent tag is empty, delete the parent to make the tag a root")
+			return
+		}
+
+		th.setParent(resp, mux.Vars(r)["tagName"], string(parent))
+	}
+}
+
+func (th *TagHandler) RemoveTagParent() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusNoContent,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		th.setParent(resp, mux.Vars(r)["tagName"], "")
+	}
+}
+
+func (th *TagHandler) setParent(resp *response, name string, parent string) {
+	tag, err := th.Config.Tags.NormalizeName(name)
+	if err != nil {
+		resp.Status = http.StatusBadRequest
+		resp.err = err
+		return
+	}
+
+	err = th.Admin.SetTagParent(string(tag), parent)
+
+	switch errors.Cause(err) {
+	case nil:
+	case providers.ErrNotFound:
+		resp.Status = http.StatusNotFound
+	case providers.ErrConflict:
+		resp.Status = http.StatusConflict
+		resp.err = errors.New("a tag cannot be nested under itself or one of its descendants")
+	default:
+		resp.Status = http.StatusInternalServerError
+		resp.err = err
+	}
+}
+
+func (th *TagHandler) TagAuditLog() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		limit := defaultTagAuditLogLimit
+		if query := r.URL.Query().Get("limit"); query != "" {
+			var err error
+			if limit, err = strconv.Atoi(query); err != nil || limit < 1 || limit > maxTagAuditLogLimit {
+				resp.Status = http.StatusBadRequest
+				resp.err = errors.Errorf("limit must be between 1 and %d", maxTagAuditLogLimit)
+				return
+			}
+		}
+
+		changes, err := th.Admin.TagAuditLog(limit)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		resp.value = &models.TagChanges{Changes: changes}
+	}
+}
+
+func (th *TagHandler) TagAliases() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		aliases, err := th.Admin.TagAliases()
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		resp.value = &models.TagAliases{Aliases: aliases}
+	}
+}
+
+func (th *TagHandler) TagAlias() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		// A name the pipeline rejects could never have been saved as an alias.
+		name, err := th.Config.Tags.NormalizeName(mux.Vars(r)["alias"])
+		if err != nil {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		alias, err := th.Admin.FindTagAlias(string(name))
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		if alias == nil {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		resp.value = alias
+	}
+}
+
+func (th *TagHandler) SaveTagAlias() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusOK,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+			return
+		}
+
+		alias := &models.TagAlias{}
+		if err = json.Unmarshal(body, alias); err != nil {
+			resp.Status = http.StatusBadRequest
+			resp.err = err
+			return
+		}
+
+		alias.Alias = mux.Vars(r)["alias"]
+		if err = alias.Normalize(th.Config.Tags); err != nil {
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = err
+			return
+		}
+
+		err = th.Admin.SaveTagAlias(alias)
+
+		switch errors.Cause(err) {
+		case nil:
+			resp.value = alias
+		case providers.ErrNotFound:
+			resp.Status = http.StatusUnprocessableEntity
+			resp.err = errors.Errorf("tag %q does not exist", alias.Tag)
+		case providers.ErrConflict:
+			resp.Status = http.StatusConflict
+			resp.err = errors.Errorf("%q is a tag, merge it instead", alias.Alias)
+		default:
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+		}
+	}
+}
+
+func (th *TagHandler) DeleteTagAlias() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &response{
+			Status: http.StatusNoContent,
+		}
+
+		defer sendResponse(w, r, resp)
+
+		name, err := th.Config.Tags.NormalizeName(mux.Vars(r)["alias"])
+		if err != nil {
+			resp.Status = http.StatusNotFound
+			return
+		}
+
+		err = th.Admin.DeleteTagAlias(string(name))
+
+		switch errors.Cause(err) {
+		case nil:
+		case providers.ErrNotFound:
+			resp.Status = http.StatusNotFound
+		default:
+			resp.Status = http.StatusInternalServerError
+			resp.err = err
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+	"github.com/eve-qunliu/articles/providers"
+)
+
+type tagAdminMock struct {
+	mock.Mock
+}
+
+func (m *tagAdminMock) DeleteTagAlias(alias string) error {
+	return m.Called(alias).Error(0)
+}
+
+func (m *tagAdminMock) FindTagAlias(alias string) (*models.TagAlias, error) {
+	rtn := m.Called(alias)
+	return rtn.Get(0).(*models.TagAlias), rtn.Error(1)
+}
+
+func (m *tagAdminMock) SaveTagAlias(alias *models.TagAlias) error {
+	return m.Called(alias).Error(0)
+}
+
+func (m *tagAdminMock) TagAliases() ([]models.TagAlias, error) {
+	rtn := m.Called()
+	return rtn.Get(0).([]models.TagAlias), rtn.Error(1)
+}
+
+func (m *tagAdminMock) MergeTags(from string, into string, actor string) error {
+	return m.Called(from, into, actor).Error(0)
+}
+
+func (m *tagAdminMock) Reindex() (*models.ReindexReport, error) {
+	rtn := m.Called()
+	return rtn.Get(0).(*models.ReindexReport), rtn.Error(1)
+}
+
+func (m *tagAdminMock) RenameTag(from string, to string, actor string) error {
+	return m.Called(from, to, actor).Error(0)
+}
+
+func (m *tagAdminMock) SetTagParent(tag string, parent string) error {
+	return m.Called(tag, parent).Error(0)
+}
+
+func (m *tagAdminMock) TagTree() ([]models.TagNode, error) {
+	rtn := m.Called()
+	return rtn.Get(0).([]models.TagNode), rtn.Error(1)
+}
+
+func (m *tagAdminMock) TagAuditLog(limit int) ([]models.TagChange, error) {
+	rtn := m.Called(limit)
+	return rtn.Get(0).([]models.TagChange), rtn.Error(1)
+}
+
+func TestRenameTag(t *testing.T) {
+	data := []struct {
+		Name           string
+		Payload        string
+		Editor         string
+		MockAdmin      func(m *tagAdminMock)
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - empty name",
+			Payload:        `{"to":"  "}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "Failure - tag not exist",
+			Payload: `{"to":"soccer"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("RenameTag", "football", "soccer", "admin").Return(providers.ErrNotFound)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:    "Failure - name taken",
+			Payload: `{"to":"soccer"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("RenameTag", "football", "soccer", "admin").Return(providers.ErrConflict)
+			},
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:    "Success - tag renamed by editor",
+			Payload: `{"to":"soccer"}`,
+			Editor:  "eve",
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("RenameTag", "football", "soccer", "eve").Return(nil)
+			},
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/admin/tags/football/rename", strings.NewReader(d.Payload))
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"tagName": "football"})
+			if d.Editor != "" {
+				r.Header.Set("X-Editor", d.Editor)
+			}
+
+			admin := new(tagAdminMock)
+			if d.MockAdmin != nil {
+				d.MockAdmin(admin)
+			}
+
+			th := handlers.TagHandler{Config: &config.Config{}, Admin: admin}
+			th.RenameTag()(w, r)
+
+			admin.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	data := []struct {
+		Name           string
+		Payload        string
+		MockAdmin      func(m *tagAdminMock)
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - invalid payload",
+			Payload:        `{"into":`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:    "Failure - db error",
+			Payload: `{"into":"soccer"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("MergeTags", "football", "soccer", "admin").Return(errors.New("unknown error"))
+			},
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:    "Success - tags merged",
+			Payload: `{"into":"soccer"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("MergeTags", "football", "soccer", "admin").Return(nil)
+			},
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("POST", "/admin/tags/football/merge", strings.NewReader(d.Payload))
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"tagName": "football"})
+
+			admin := new(tagAdminMock)
+			if d.MockAdmin != nil {
+				d.MockAdmin(admin)
+			}
+
+			th := handlers.TagHandler{Config: &config.Config{}, Admin: admin}
+			th.MergeTags()(w, r)
+
+			admin.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+		})
+	}
+}
+
+func TestTagAuditLog(t *testing.T) {
+	changes := []models.TagChange{{Action: models.TagMerged, Actor: "eve", From: "football", ID: 2, To: "soccer"}}
+
+	data := []struct {
+		Name           string
+		Query          string
+		MockAdmin      func(m *tagAdminMock)
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Failure - limit out of range",
+			Query:          "?limit=1000",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name: "Success - default limit",
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("TagAuditLog", 50).Return(changes, nil)
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"action":"merge","actor":"eve"`,
+		},
+		{
+			Name:  "Success - given limit",
+			Query: "?limit=5",
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("TagAuditLog", 5).Return([]models.TagChange{}, nil)
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"changes":[]}`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/admin/tags/audit-log"+d.Query, nil)
+			assert.NoError(t, err, "failed to create request")
+
+			admin := new(tagAdminMock)
+			if d.MockAdmin != nil {
+				d.MockAdmin(admin)
+			}
+
+			th := handlers.TagHandler{Config: &config.Config{}, Admin: admin}
+			th.TagAuditLog()(w, r)
+
+			admin.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+		})
+	}
+}
+
+func TestSaveTagAlias(t *testing.T) {
+	// The pipeline leaves out casefold; aliases are lowercased like article tags.
+	aliasTags, err := models.NewTagPipeline([]string{"trim", "hyphenate", "charset"}, models.TagRules{AllowedCharacters: `\p{L}-`})
+	assert.NoError(t, err, "pipeline")
+
+	data := []struct {
+		Name           string
+		Alias          string
+		Payload        string
+		MockAdmin      func(m *tagAdminMock)
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Failure - alias of itself",
+			Alias:          "NYC",
+			Payload:        `{"tag":"nyc"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "Failure - tag not exist",
+			Alias:   "nyc",
+			Payload: `{"tag":"new-york"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SaveTagAlias", &models.TagAlias{Alias: "nyc", Tag: "new-york"}).Return(providers.ErrNotFound)
+			},
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "Failure - alias is a tag",
+			Alias:   "nyc",
+			Payload: `{"tag":"new-york"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SaveTagAlias", &models.TagAlias{Alias: "nyc", Tag: "new-york"}).Return(providers.ErrConflict)
+			},
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Failure - alias rejected by the pipeline",
+			Alias:          "rock&roll",
+			Payload:        `{"tag":"rock"}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "Success - alias normalized by the pipeline",
+			Alias:   "New York City",
+			Payload: `{"tag":"  NEW   York "}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SaveTagAlias", &models.TagAlias{Alias: "new-york-city", Tag: "new-york"}).Return(nil)
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"alias":"new-york-city"`,
+		},
+		{
+			Name:    "Success - alias saved",
+			Alias:   "NYC",
+			Payload: `{"tag":"New-York "}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SaveTagAlias", &models.TagAlias{Alias: "nyc", Tag: "new-york"}).Return(nil)
+			},
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `"alias":"nyc"`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("PUT", "/admin/tag-aliases/"+d.Alias, strings.NewReader(d.Payload))
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"alias": d.Alias})
+
+			admin := new(tagAdminMock)
+			if d.MockAdmin != nil {
+				d.MockAdmin(admin)
+			}
+
+			th := handlers.TagHandler{Config: &config.Config{Tags: aliasTags}, Admin: admin}
+			th.SaveTagAlias()(w, r)
+
+			admin.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+			assert.Contains(t, w.Body.String(), d.ExpectedBody, "body")
+		})
+	}
+}
+
+func TestDeleteTagAlias(t *testing.T) {
+	data := []struct {
+		Name           string
+		Err            error
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - alias not exist",
+			Err:            providers.ErrNotFound,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Success - alias deleted",
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("DELETE", "/admin/tag-aliases/NYC", nil)
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"alias": "NYC"})
+
+			admin := new(tagAdminMock)
+			admin.On("DeleteTagAlias", "nyc").Return(d.Err)
+
+			th := handlers.TagHandler{Config: &config.Config{}, Admin: admin}
+			th.DeleteTagAlias()(w, r)
+
+			admin.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+		})
+	}
+}
+
+func TestTagTree(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/admin/tags/tree", nil)
+	assert.NoError(t, err, "failed to create request")
+
+	admin := new(tagAdminMock)
+	admin.On("TagTree").Return([]models.TagNode{
+		{Name: "sports", Children: []models.TagNode{{Name: "football", Children: []models.TagNode{}}}},
+	}, nil)
+
+	th := handlers.TagHandler{Config: &config.Config{}, Admin: admin}
+	th.TagTree()(w, r)
+
+	admin.Mock.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code, "status")
+	assert.JSONEq(t, `{"tags":[{"name":"sports","children":[{"name":"football","children":[]}]}]}`, w.Body.String(), "body")
+}
+
+func TestSetTagParent(t *testing.T) {
+	data := []struct {
+		Name           string
+		Method         string
+		Payload        string
+		MockAdmin      func(m *tagAdminMock)
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - empty parent",
+			Method:         "PUT",
+			Payload:        `{"parent":" "}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:    "Failure - tag not exist",
+			Method:  "PUT",
+			Payload: `{"parent":"sports"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SetTagParent", "football", "sports").Return(providers.ErrNotFound)
+			},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:    "Failure - cycle",
+			Method:  "PUT",
+			Payload: `{"parent":"premier-league"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SetTagParent", "football", "premier-league").Return(providers.ErrConflict)
+			},
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:    "Success - tag nested",
+			Method:  "PUT",
+			Payload: `{"parent":"sports"}`,
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SetTagParent", "football", "sports").Return(nil)
+			},
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:   "Success - parent removed",
+			Method: "DELETE",
+			MockAdmin: func(m *tagAdminMock) {
+				m.On("SetTagParent", "football", "").Return(nil)
+			},
+			ExpectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest(d.Method, "/admin/tags/football/parent", strings.NewReader(d.Payload))
+			assert.NoError(t, err, "failed to create request")
+			r = mux.SetURLVars(r, map[string]string{"tagName": "football"})
+
+			admin := new(tagAdminMock)
+			if d.MockAdmin != nil {
+				d.MockAdmin(admin)
+			}
+
+			th := handlers.TagHandler{Config: &config.Config{}, Admin: admin}
+			if d.Method == "DELETE" {
+				th.RemoveTagParent()(w, r)
+			} else {
+				th.SetTagParent()(w, r)
+			}
+
+			admin.Mock.AssertExpectations(t)
+			assert.Equal(t, d.ExpectedStatus, w.Code, "status")
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// streamingRoutes are left to the server's read and write timeouts.
+var streamingRoutes = map[string]bool{"importArticles": true, "exportArticles": true}
+
+func timeout(limit time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+
+		limited := http.TimeoutHandler(next, limit, "request timed out")
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil && streamingRoutes[route.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestTimeout(t *testing.T) {
+	article := &models.Article{ID: 1, Title: "z1", Body: "z3", Date: "2018-06-12", Tags: []models.Tag{}, Status: models.StatusPublished}
+
+	data := []struct {
+		Name           string
+		Path           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Failure - request outlives the request timeout",
+			Path:           "/articles/1",
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			Name:           "Success - streaming route outlives the request timeout",
+			Path:           "/export",
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			provider := new(dataProviderMock)
+			provider.OnFindArticle("1").Return(article, nil).After(200 * time.Millisecond)
+			provider.On("ExportArticles", mock.Anything).Return([]*models.Article{article}, nil).After(200 * time.Millisecond)
+
+			cfg := &config.Config{AdminToken: "secret", RequestTimeout: 50 * time.Millisecond, StreamTimeout: time.Minute}
+			server := httptest.NewServer(handlers.NewHandler(cfg, providerMock{provider, nil, nil}))
+			defer server.Close()
+
+			r, err := http.NewRequest("GET", server.URL+d.Path, nil)
+			assert.NoError(t, err, "failed to create request")
+			r.Header.Set("Authorization", "Bearer secret")
+
+			resp, err := http.DefaultClient.Do(r)
+			assert.NoError(t, err, "request")
+			resp.Body.Close()
+
+			assert.Equal(t, d.ExpectedStatus, resp.StatusCode, "status")
+		})
+	}
+}
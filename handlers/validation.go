@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Pattern              string             `json:"pattern"`
+	Enum                 []interface{}      `json:"enum"`
+	Nullable             bool               `json:"nullable"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+}
+
+type parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type operation struct {
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	// Streaming bodies are not buffered and have their own limit.
+	Streaming bool `json:"x-streaming"`
+}
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas    map[string]*schema    `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type validator struct {
+	document           *openAPIDocument
+	operations         map[string]*operation
+	patterns           sync.Map
+	maxBodyBytes       int64
+	maxStreamBodyBytes int64
+}
+
+const (
+	defaultMaxBodyBytes       = 1 << 20
+	defaultMaxStreamBodyBytes = 1 << 30
+)
+
+func newValidator(spec []byte, maxBodyBytes int64, maxStreamBodyBytes int64) (*validator, error) {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+
+	if maxStreamBodyBytes <= 0 {
+		maxStreamBodyBytes = defaultMaxStreamBodyBytes
+	}
+
+	v := &validator{
+		document:           &openAPIDocument{},
+		operations:         make(map[string]*operation),
+		maxBodyBytes:       maxBodyBytes,
+		maxStreamBodyBytes: maxStreamBodyBytes,
+	}
+
+	if err := json.Unmarshal(spec, v.document); err != nil {
+		return nil, errors.Wrap(err, "invalid OpenAPI document")
+	}
+
+	for path, item := range v.document.Paths {
+		var shared []*parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, errors.Wrapf(err, "invalid parameters of %s", path)
+			}
+		}
+
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+
+			op := &operation{}
+			if err := json.Unmarshal(raw, op); err != nil {
+				return nil, errors.Wrapf(err, "invalid operation %s %s", method, path)
+			}
+
+			op.Parameters = append(append([]*parameter{}, shared...), op.Parameters...)
+			for idx, param := range op.Parameters {
+				op.Parameters[idx] = v.parameter(param)
+			}
+
+			v.operations[strings.ToUpper(method)+" "+path] = op
+		}
+	}
+
+	return v, nil
+}
+
+func (v *validator) parameter(param *parameter) *parameter {
+	if param.Ref == "" {
+		return param
+	}
+
+	return v.document.Components.Parameters[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+}
+
+func (v *validator) resolve(s *schema) *schema {
+	for s != nil && s.Ref != "" {
+		s = v.document.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+
+	return s
+}
+
+// Middleware rejects requests that do not match their documented operation.
+func (v *validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := v.operation(r)
+
+		// Bodies validated below are capped as they are buffered.
+		switch {
+		case r.Body == nil:
+		case op == nil || op.RequestBody == nil:
+			r.Body = http.MaxBytesReader(w, r.Body, v.maxBodyBytes)
+		case op.Streaming:
+			r.Body = http.MaxBytesReader(w, r.Body, v.maxStreamBodyBytes)
+		}
+
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fields := v.parameters(r, op); len(fields) > 0 {
+			v.reject(w, r, http.StatusBadRequest, errors.New("invalid request parameters"), fields)
+			return
+		}
+
+		if op.RequestBody == nil || op.Streaming {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status, fields, err := v.body(r, op)
+		if status != http.StatusOK {
+			v.reject(w, r, status, err, fields)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (v *validator) operation(r *http.Request) *operation {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+
+	return v.operations[r.Method+" "+template]
+}
+
+func (v *validator) reject(w http.ResponseWriter, r *http.Request, status int, err error, fields []invalidField) {
+	resp := &response{Status: status, err: err}
+	describeProblem(resp, fields)
+	sendResponse(w, r, resp)
+}
+
+func (v *validator) parameters(r *http.Request, op *operation) []invalidField {
+	var fields []invalidField
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	for _, param := range op.Parameters {
+		if param == nil {
+			continue
+		}
+
+		var value string
+		var present bool
+
+		switch param.In {
+		case "path":
+			value, present = vars[param.Name]
+		case "query":
+			_, present = query[param.Name]
+			value = query.Get(param.Name)
+		case "header":
+			value = r.Header.Get(param.Name)
+			present = value != ""
+		default:
+			continue
+		}
+
+		location := param.In + "." + param.Name
+		if !present {
+			if param.Required {
+				fields = append(fields, invalidField{location, "is required"})
+			}
+			continue
+		}
+
+		fields = append(fields, v.validate(location, parameterValue(value, v.resolve(param.Schema)), param.Schema)...)
+	}
+
+	return fields
+}
+
+// parameterValue leaves values that do not parse as strings so validation reports them.
+func parameterValue(value string, s *schema) interface{} {
+	if s == nil {
+		return value
+	}
+
+	switch s.Type {
+	case "integer", "number":
+		return json.Number(value)
+	case "boolean":
+		switch value {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+
+	return value
+}
+
+func (v *validator) body(r *http.Request, op *operation) (int, []invalidField, error) {
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return http.StatusUnsupportedMediaType, nil, errors.Wrap(err, "invalid Content-Type")
+		}
+	}
+
+	content, ok := op.RequestBody.Content[mediaType]
+	if !ok {
+		return http.StatusUnsupportedMediaType, nil, errors.Errorf("unsupported Content-Type %s", mediaType)
+	}
+
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, v.maxBodyBytes+1))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "failed to read body")
+	}
+
+	if int64(len(payload)) > v.maxBodyBytes {
+		return http.StatusRequestEntityTooLarge, nil, errors.Errorf("body is larger than %d bytes", v.maxBodyBytes)
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+
+	if len(bytes.TrimSpace(payload)) == 0 {
+		if op.RequestBody.Required {
+			return http.StatusBadRequest, nil, errors.New("body is required")
+		}
+		return http.StatusOK, nil, nil
+	}
+
+	if !strings.HasSuffix(mediaType, "json") || content.Schema == nil {
+		return http.StatusOK, nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err = decoder.Decode(&document); err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "body is not valid JSON")
+	}
+
+	if fields := v.validate("body", document, content.Schema); len(fields) > 0 {
+		return http.StatusUnprocessableEntity, fields, errors.New("body does not match the schema")
+	}
+
+	return http.StatusOK, nil, nil
+}
+
+func (v *validator) validate(location string, value interface{}, s *schema) []invalidField {
+	s = v.resolve(s)
+	if s == nil {
+		return nil
+	}
+
+	if value == nil {
+		if s.Nullable {
+			return nil
+		}
+		return []invalidField{{location, "must not be null"}}
+	}
+
+	invalid := func(format string, args ...interface{}) []invalidField {
+		return []invalidField{{location, fmt.Sprintf(format, args...)}}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid("must be an object")
+		}
+		return v.validateObject(location, object, s)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return invalid("must be an array")
+		}
+
+		var fields []invalidField
+		for idx, item := range array {
+			fields = append(fields, v.validate(fmt.Sprintf("%s[%d]", location, idx), item, s.Items)...)
+		}
+		return fields
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return invalid("must be a %s", s.Type)
+		}
+
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				return invalid("must be an integer")
+			}
+		}
+
+		float, err := number.Float64()
+		if err != nil {
+			return invalid("must be a number")
+		}
+
+		if s.Minimum != nil && float < *s.Minimum {
+			return invalid("must be at least %v", *s.Minimum)
+		}
+
+		if s.Maximum != nil && float > *s.Maximum {
+			return invalid("must be at most %v", *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return invalid("must be a boolean")
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return invalid("must be a string")
+		}
+		return v.validateString(location, str, s)
+	}
+
+	return nil
+}
+
+func (v *validator) validateObject(location string, object map[string]interface{}, s *schema) []invalidField {
+	var fields []invalidField
+
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			fields = append(fields, invalidField{location + "." + name, "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fields = append(fields, invalidField{location + "." + name, "is not a known field"})
+			}
+			continue
+		}
+
+		fields = append(fields, v.validate(location+"."+name, object[name], property)...)
+	}
+
+	return fields
+}
+
+func (v *validator) validateString(location string, str string, s *schema) []invalidField {
+	invalid := func(format string, args ...interface{}) []invalidField {
+		return []invalidField{{location, fmt.Sprintf(format, args...)}}
+	}
+
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		return invalid("must be at least %d characters long", *s.MinLength)
+	}
+
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return invalid("must be at most %d characters long", *s.MaxLength)
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := make([]string, 0, len(s.Enum))
+		for _, option := range s.Enum {
+			if option == str {
+				return nil
+			}
+			allowed = append(allowed, fmt.Sprint(option))
+		}
+		return invalid("must be one of %s", strings.Join(allowed, ", "))
+	}
+
+	switch s.Format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return invalid("must be a date formatted YYYY-MM-DD")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			return invalid("must be an RFC 3339 date-time")
+		}
+	}
+
+	if s.Pattern != "" {
+		compiled, ok := v.patterns.Load(s.Pattern)
+		if !ok {
+			pattern, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return invalid("cannot be checked against %s", s.Pattern)
+			}
+			compiled, _ = v.patterns.LoadOrStore(s.Pattern, pattern)
+		}
+
+		if !compiled.(*regexp.Regexp).MatchString(str) {
+			return invalid("must match %s", s.Pattern)
+		}
+	}
+
+	return nil
+}
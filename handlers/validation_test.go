@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eve-qunliu/articles/config"
+	"github.com/eve-qunliu/articles/handlers"
+)
+
+type problem struct {
+	Detail string `json:"detail"`
+	Errors []struct {
+		Location string `json:"location"`
+		Message  string `json:"message"`
+	} `json:"errors"`
+	Status int `json:"status"`
+}
+
+func TestValidation(t *testing.T) {
+	data := []struct {
+		Name        string
+		Method      string
+		URL         string
+		ContentType string
+		Body        string
+		Status      int
+		Detail      string
+		Locations   []string
+	}{
+		{
+			Name:      "Failure - invalid date path parameter",
+			Method:    "GET",
+			URL:       "/tag/sports/garbage",
+			Status:    http.StatusBadRequest,
+			Detail:    "invalid request parameters",
+			Locations: []string{"path.date"},
+		},
+		{
+			Name:      "Failure - non numeric article id",
+			Method:    "GET",
+			URL:       "/articles/abc",
+			Status:    http.StatusBadRequest,
+			Detail:    "invalid request parameters",
+			Locations: []string{"path.id"},
+		},
+		{
+			Name:      "Failure - invalid query parameters",
+			Method:    "GET",
+			URL:       "/export?from=2018-6-1&gzip=yes",
+			Status:    http.StatusBadRequest,
+			Detail:    "invalid request parameters",
+			Locations: []string{"query.from", "query.gzip"},
+		},
+		{
+			Name:      "Failure - unknown and missing fields",
+			Method:    "POST",
+			URL:       "/articles",
+			Body:      `{"author":"z","body":"z3","date":"2018-06-12","tags":[1]}`,
+			Status:    http.StatusUnprocessableEntity,
+			Detail:    "body does not match the schema",
+			Locations: []string{"body.title", "body.author", "body.tags[0]"},
+		},
+		{
+			Name:   "Failure - malformed JSON",
+			Method: "PUT",
+			URL:    "/articles/1",
+			Body:   `{"title":`,
+			Status: http.StatusBadRequest,
+			Detail: "body is not valid JSON: unexpected EOF",
+		},
+		{
+			Name:        "Failure - unsupported content type",
+			Method:      "POST",
+			URL:         "/articles",
+			ContentType: "text/plain",
+			Body:        "z1",
+			Status:      http.StatusUnsupportedMediaType,
+			Detail:      "unsupported Content-Type text/plain",
+		},
+		{
+			Name:   "Failure - body too large",
+			Method: "POST",
+			URL:    "/articles",
+			Body:   `{"title":"` + strings.Repeat("z", 64) + `"}`,
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: "body is larger than 64 bytes",
+		},
+	}
+
+	router := handlers.NewHandler(&config.Config{MaxBodyBytes: 64}, nil)
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			r, err := http.NewRequest(d.Method, d.URL, strings.NewReader(d.Body))
+			require.NoError(t, err, "failed to create request")
+			if d.ContentType != "" {
+				r.Header.Set("Content-Type", d.ContentType)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, d.Status, w.Code, "status")
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"), "content type")
+
+			body := &problem{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), body), "problem body")
+			assert.Equal(t, d.Status, body.Status, "problem status")
+			assert.Equal(t, d.Detail, body.Detail, "detail")
+
+			var locations []string
+			for _, field := range body.Errors {
+				locations = append(locations, field.Location)
+			}
+			assert.Equal(t, d.Locations, locations, "invalid fields")
+		})
+	}
+}
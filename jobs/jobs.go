@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var logger *zap.SugaredLogger
+
+func init() {
+	baseLogger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	logger = baseLogger.Sugar()
+}
+
+// Every logs failures and retries them on the next tick.
+func Every(interval time.Duration, name string, stop <-chan struct{}, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(); err != nil {
+			logger.Errorf("job %q failed: %s", name, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,3 @@
+DROP TRIGGER increment_articles_version ON articles;
+ALTER TABLE articles DROP COLUMN version;
+DROP FUNCTION increment_version();
@@ -0,0 +1,11 @@
+CREATE OR REPLACE FUNCTION increment_version()
+  RETURNS TRIGGER AS $$
+BEGIN
+  NEW.version = OLD.version + 1;
+  RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+ALTER TABLE articles ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+CREATE TRIGGER increment_articles_version BEFORE UPDATE ON articles FOR EACH ROW EXECUTE PROCEDURE increment_version();
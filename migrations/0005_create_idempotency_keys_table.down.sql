@@ -0,0 +1,3 @@
+DROP TRIGGER update_idempotency_keys ON idempotency_keys;
+DROP INDEX index_idempotency_keys_on_created_at;
+DROP TABLE idempotency_keys;
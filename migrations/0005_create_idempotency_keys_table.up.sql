@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys
+(
+  key           varchar(255) PRIMARY KEY,
+  request_hash  char(64) NOT NULL,
+  status        integer NOT NULL DEFAULT 0,
+  response      bytea,
+  created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX index_idempotency_keys_on_created_at ON idempotency_keys (created_at);
+CREATE TRIGGER update_idempotency_keys BEFORE UPDATE ON idempotency_keys FOR EACH ROW EXECUTE PROCEDURE set_updated_at();
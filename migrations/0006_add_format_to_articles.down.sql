@@ -0,0 +1,2 @@
+ALTER TABLE articles DROP COLUMN body_html;
+ALTER TABLE articles DROP COLUMN format;
@@ -0,0 +1,3 @@
+ALTER TABLE articles ADD COLUMN format text NOT NULL DEFAULT 'plain'
+  CHECK (format IN ('plain', 'markdown', 'html'));
+ALTER TABLE articles ADD COLUMN body_html text NOT NULL DEFAULT '';
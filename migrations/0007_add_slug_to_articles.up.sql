@@ -0,0 +1,19 @@
+ALTER TABLE articles ADD COLUMN slug text;
+
+UPDATE articles SET slug = coalesce(nullif(trim(both '-' from lower(regexp_replace(title, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'article');
+UPDATE articles SET slug = slug || '-' || id
+  WHERE id > (SELECT min(id) FROM articles AS same WHERE same.slug = articles.slug);
+
+ALTER TABLE articles ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE articles ADD CONSTRAINT articles_slug_key UNIQUE (slug);
+
+CREATE TABLE article_slugs
+(
+  slug          text PRIMARY KEY,
+  article_id    integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+  created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX index_article_slugs_on_article_id ON article_slugs (article_id);
+
+INSERT INTO article_slugs (slug, article_id) SELECT slug, id FROM articles;
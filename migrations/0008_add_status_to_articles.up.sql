@@ -0,0 +1,6 @@
+ALTER TABLE articles ADD COLUMN status text NOT NULL DEFAULT 'published'
+  CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+ALTER TABLE articles ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE articles ADD CONSTRAINT articles_scheduled_publish_at CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+CREATE INDEX index_articles_on_publish_at_scheduled ON articles (publish_at) WHERE status = 'scheduled';
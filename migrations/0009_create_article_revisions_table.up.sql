@@ -0,0 +1,21 @@
+CREATE TABLE article_revisions
+(
+  id            serial PRIMARY KEY,
+  article_id    integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+  revision      integer NOT NULL,
+  title         TEXT NOT NULL,
+  body          TEXT NOT NULL,
+  date          varchar(255) NOT NULL,
+  format        text NOT NULL,
+  tags          text[] NOT NULL DEFAULT '{}',
+  editor        text NOT NULL DEFAULT '',
+  created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (article_id, revision)
+);
+
+INSERT INTO article_revisions (article_id, revision, title, body, date, format, tags, created_at)
+SELECT articles.id, 1, articles.title, articles.body, articles.date, articles.format,
+  array_remove(array_agg(tags.name ORDER BY tags_articles.id), NULL), articles.updated_at
+FROM articles LEFT JOIN tags_articles ON articles.id = tags_articles.article_id
+LEFT JOIN tags ON tags.id = tags_articles.tag_id
+GROUP BY articles.id;
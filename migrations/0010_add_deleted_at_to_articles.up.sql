@@ -0,0 +1,3 @@
+ALTER TABLE articles ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX index_articles_on_deleted_at ON articles (deleted_at) WHERE deleted_at IS NOT NULL;
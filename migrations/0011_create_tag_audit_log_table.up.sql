@@ -0,0 +1,11 @@
+CREATE TABLE tag_audit_log
+(
+  id          SERIAL PRIMARY KEY,
+  action      text NOT NULL CHECK (action IN ('rename', 'merge')),
+  from_tag    text NOT NULL,
+  to_tag      text NOT NULL,
+  actor       text NOT NULL DEFAULT '',
+  created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX index_tag_audit_log_on_created_at ON tag_audit_log (created_at);
@@ -0,0 +1,8 @@
+CREATE TABLE tag_aliases
+(
+  alias       text PRIMARY KEY,
+  tag_id      integer NOT NULL REFERENCES tags ON DELETE CASCADE,
+  created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX index_tag_aliases_on_tag_id ON tag_aliases (tag_id);
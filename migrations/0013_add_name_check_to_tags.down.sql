@@ -0,0 +1 @@
+ALTER TABLE tags DROP CONSTRAINT tags_name_check;
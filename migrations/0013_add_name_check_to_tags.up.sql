@@ -0,0 +1,3 @@
+-- NOT VALID leaves tags written before the normalization pipeline alone until
+-- tags normalize cleans them up and validates the constraint.
+ALTER TABLE tags ADD CONSTRAINT tags_name_check CHECK (name <> '' AND name = btrim(name)) NOT VALID;
@@ -0,0 +1,2 @@
+DROP INDEX index_tags_articles_on_article_id_and_position;
+ALTER TABLE tags_articles DROP COLUMN position;
@@ -0,0 +1,8 @@
+ALTER TABLE tags_articles ADD COLUMN position integer NOT NULL DEFAULT 0;
+
+-- Existing articles keep the order their tags were mapped in.
+UPDATE tags_articles SET position = numbered.position
+FROM (SELECT id, row_number() OVER (PARTITION BY article_id ORDER BY id) - 1 AS position FROM tags_articles) AS numbered
+WHERE tags_articles.id = numbered.id;
+
+CREATE INDEX index_tags_articles_on_article_id_and_position ON tags_articles (article_id, position);
@@ -0,0 +1,2 @@
+DROP INDEX index_tags_on_parent_id;
+ALTER TABLE tags DROP COLUMN parent_id;
@@ -0,0 +1,4 @@
+ALTER TABLE tags ADD COLUMN parent_id integer REFERENCES tags ON DELETE SET NULL;
+ALTER TABLE tags ADD CONSTRAINT tags_parent_id_check CHECK (parent_id <> id);
+
+CREATE INDEX index_tags_on_parent_id ON tags (parent_id) WHERE parent_id IS NOT NULL;
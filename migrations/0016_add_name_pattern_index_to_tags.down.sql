@@ -0,0 +1 @@
+DROP INDEX index_tags_on_name_pattern;
@@ -0,0 +1,3 @@
+-- The unique index on name follows the database collation, which LIKE 'prefix%'
+-- cannot use outside the C locale; text_pattern_ops can.
+CREATE INDEX index_tags_on_name_pattern ON tags (name text_pattern_ops);
@@ -0,0 +1,83 @@
+// Package migrations tracks versions in the golang-migrate schema_migrations
+// table, so databases migrated with either stay compatible.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+var fileName = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func Load() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list migrations")
+	}
+
+	return parse(names, func(name string) (string, error) {
+		content, err := files.ReadFile(name)
+		return string(content), err
+	})
+}
+
+func parse(names []string, read func(string) (string, error)) ([]Migration, error) {
+	byVersion := make(map[int64]*Migration)
+
+	for _, name := range names {
+		match := fileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, errors.Errorf("migration %s is not named VERSION_NAME.(up|down).sql", name)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "migration %s has an invalid version", name)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		content, err := read(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read migration %s", name)
+		}
+
+		if match[3] == "up" {
+			migration.Up = content
+		} else {
+			migration.Down = content
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, errors.Errorf("migration %d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
@@ -0,0 +1,119 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eve-qunliu/articles/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	loaded, err := migrations.Load()
+	require.NoError(t, err, "failed to load embedded migrations")
+	require.NotEmpty(t, loaded, "embedded migrations")
+
+	for idx, migration := range loaded {
+		assert.Equal(t, int64(idx+1), migration.Version, "%s: version", migration.Name)
+		assert.NotEmpty(t, migration.Up, "%s: up script", migration.Name)
+		assert.NotEmpty(t, migration.Down, "%s: down script", migration.Name)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	scripts := []migrations.Migration{
+		{Version: 1, Name: "create_a", Up: "CREATE TABLE a", Down: "DROP TABLE a"},
+		{Version: 2, Name: "create_b", Up: "CREATE TABLE b", Down: "DROP TABLE b"},
+	}
+	data := []struct {
+		Name           string
+		Run            func(r *migrations.Runner) (int, error)
+		Count          int
+		MockOperations func(m sqlmock.Sqlmock)
+		VerifyError    func(t *testing.T, err error)
+	}{
+		{
+			Name:  "Success - up applies pending migrations",
+			Run:   func(r *migrations.Runner) (int, error) { return r.Up() },
+			Count: 1,
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectVersion(m, 1, false)
+				expectApply(m, "CREATE TABLE b", 2)
+				expectUnlock(m)
+			},
+		},
+		{
+			Name:  "Success - down reverts to the previous version",
+			Run:   func(r *migrations.Runner) (int, error) { return r.Down(2) },
+			Count: 2,
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectVersion(m, 2, false)
+				expectApply(m, "DROP TABLE b", 1)
+				expectApply(m, "DROP TABLE a", 0)
+				expectUnlock(m)
+			},
+		},
+		{
+			Name: "Failure - dirty database",
+			Run:  func(r *migrations.Runner) (int, error) { return r.Up() },
+			MockOperations: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectVersion(m, 2, true)
+				expectUnlock(m)
+			},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "database is dirty at version 2, fix it and force a version", "Error")
+			},
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create SqlMock DB")
+			defer db.Close()
+
+			d.MockOperations(mock)
+			runner := &migrations.Runner{DB: db, Migrations: scripts}
+
+			count, err := d.Run(runner)
+
+			assert.NoError(t, mock.ExpectationsWereMet(), "%s: DB Expectations", d.Name)
+			if d.VerifyError != nil {
+				d.VerifyError(t, err)
+				return
+			}
+			assert.NoError(t, err, "Error: %s", d.Name)
+			assert.Equal(t, d.Count, count, "%s: count", d.Name)
+		})
+	}
+}
+
+func expectLock(m sqlmock.Sqlmock) {
+	m.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(m sqlmock.Sqlmock) {
+	m.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectVersion(m sqlmock.Sqlmock, version int64, dirty bool) {
+	m.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty))
+}
+
+func expectApply(m sqlmock.Sqlmock, script string, version int64) {
+	m.ExpectBegin()
+	m.ExpectExec(script).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	if version != 0 {
+		m.ExpectExec(`INSERT INTO schema_migrations \(version, dirty\) VALUES \(\$1, false\)`).WithArgs(version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	m.ExpectCommit()
+}
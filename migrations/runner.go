@@ -0,0 +1,214 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// lockKey is "articles" in ASCII.
+const lockKey int64 = 0x61727469636c6573
+
+type Runner struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+type Status struct {
+	Version int64
+	Dirty   bool
+	Pending []Migration
+}
+
+func NewRunner(db *sql.DB) (*Runner, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{DB: db, Migrations: migrations}, nil
+}
+
+func (r *Runner) Up() (int, error) {
+	applied := 0
+
+	err := r.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		current, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range r.Migrations {
+			if migration.Version <= current {
+				continue
+			}
+
+			if err = apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return errors.Wrapf(err, "failed to apply migration %d_%s", migration.Version, migration.Name)
+			}
+
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+func (r *Runner) Down(n int) (int, error) {
+	reverted := 0
+
+	err := r.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		current, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for idx := len(r.Migrations) - 1; idx >= 0 && reverted < n; idx-- {
+			migration := r.Migrations[idx]
+			if migration.Version > current {
+				continue
+			}
+
+			var previous int64
+			if idx > 0 {
+				previous = r.Migrations[idx-1].Version
+			}
+
+			if err = apply(ctx, conn, migration.Down, previous); err != nil {
+				return errors.Wrapf(err, "failed to revert migration %d_%s", migration.Version, migration.Name)
+			}
+
+			reverted++
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+func (r *Runner) Status() (*Status, error) {
+	status := &Status{}
+
+	err := r.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		var err error
+		if status.Version, status.Dirty, err = version(ctx, conn); err != nil {
+			return err
+		}
+
+		for _, migration := range r.Migrations {
+			if migration.Version > status.Version {
+				status.Pending = append(status.Pending, migration)
+			}
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+// Force recovers from a migration that failed halfway. Zero clears the version.
+func (r *Runner) Force(version int64) error {
+	if version != 0 && !r.known(version) {
+		return errors.Errorf("unknown migration version %d", version)
+	}
+
+	return r.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		return apply(ctx, conn, "", version)
+	})
+}
+
+func (r *Runner) known(version int64) bool {
+	for _, migration := range r.Migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Runner) withLock(fn func(context.Context, *sql.Conn) error) error {
+	ctx := context.Background()
+
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect DB")
+	}
+
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return errors.Wrap(err, "failed to acquire migration lock")
+	}
+
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create schema_migrations table")
+	}
+
+	return fn(ctx, conn)
+}
+
+func version(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to read schema version")
+	}
+
+	return version, dirty, nil
+}
+
+func cleanVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	current, dirty, err := version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, errors.Errorf("database is dirty at version %d, fix it and force a version", current)
+	}
+
+	return current, nil
+}
+
+func apply(ctx context.Context, conn *sql.Conn, script string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback()
+
+	if script != "" {
+		if _, err = tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return errors.Wrap(err, "failed to clear schema version")
+	}
+
+	if version != 0 {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+		if err != nil {
+			return errors.Wrap(err, "failed to record schema version")
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit migration")
+}
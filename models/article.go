@@ -1,17 +1,29 @@
 package models
 
 import (
+	"encoding/xml"
 	"regexp"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 type Article struct {
-	Body  string `json:"body"`
-	Date  string `json:"date"`
-	ID    int64  `json:"id,string"`
-	Tags  []Tag  `json:"tags"`
-	Title string `json:"title"`
+	XMLName   xml.Name   `json:"-" xml:"article"`
+	Body      string     `json:"body" xml:"body"`
+	BodyHTML  string     `json:"-" xml:"-"`
+	CreatedAt time.Time  `json:"created_at" xml:"created_at"`
+	Date      string     `json:"date" xml:"date"`
+	Editor    string     `json:"-" xml:"-"`
+	Format    string     `json:"format,omitempty" xml:"format,omitempty"`
+	ID        int64      `json:"id,string" xml:"id"`
+	PublishAt *time.Time `json:"publish_at,omitempty" xml:"publish_at,omitempty"`
+	Slug      string     `json:"slug,omitempty" xml:"slug,omitempty"`
+	Status    string     `json:"status,omitempty" xml:"status,omitempty"`
+	Tags      []Tag      `json:"tags" xml:"tags>tag"`
+	Title     string     `json:"title" xml:"title"`
+	UpdatedAt time.Time  `json:"updated_at" xml:"updated_at"`
+	Version   int64      `json:"version" xml:"version"`
 }
 
 func (article *Article) invalidDate() error {
@@ -37,15 +49,35 @@ func (article *Article) Invalid(tagLimit int) error {
 		return errors.New("too many tags")
 	}
 
+	if err := article.invalidFormat(); err != nil {
+		return err
+	}
+
+	if err := article.invalidStatus(); err != nil {
+		return err
+	}
+
 	return article.invalidDate()
 }
 
-func (article *Article) Normalize(tagLimit int) error {
+// Normalize derives the slug from the title; the provider makes it unique.
+func (article *Article) Normalize(tagLimit int, tags TagPipeline) error {
+	for idx, tag := range article.Tags {
+		normalized, err := tags.Normalize(tag)
+		if err != nil {
+			return err
+		}
+
+		article.Tags[idx] = normalized
+	}
+
 	if err := article.Invalid(tagLimit); err != nil {
 		return err
 	}
 
 	article.Tags = uniqTags(article.Tags)
+	article.Render()
+	article.Slug = Slugify(article.Title)
 
 	return nil
 }
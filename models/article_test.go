@@ -41,6 +41,13 @@ func TestInvalid(t *testing.T) {
 				assert.EqualError(t, err, "too many tags", "Error")
 			},
 		},
+		{
+			Name:    "Failure - unknown format",
+			Article: &models.Article{Body: "z3", Date: "2018-06-12", Format: "rtf", ID: 123, Title: "z1"},
+			VerifyError: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "format must be one of plain, markdown or html", "Error")
+			},
+		},
 		{
 			Name:    "Failure - invalid date format",
 			Article: &models.Article{Body: "z3", Date: "2018", ID: 123, Tags: []models.Tag{"music", "sports"}, Title: "z1"},
@@ -85,6 +92,11 @@ func TestNormalize(t *testing.T) {
 			Article: &models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"music", "music", "sports"}, Title: "z1"},
 			Tags:    []models.Tag{"music", "sports"},
 		},
+		{
+			Name:    "Success - with tag order kept",
+			Article: &models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"sports", "Music", "music"}, Title: "z1"},
+			Tags:    []models.Tag{"sports", "music"},
+		},
 		{
 			Name:    "Success - with empty tag removed",
 			Article: &models.Article{Body: "z3", Date: "2018-06-12", ID: 123, Tags: []models.Tag{"", "music", "sports"}, Title: "z1"},
@@ -94,7 +106,7 @@ func TestNormalize(t *testing.T) {
 
 	for _, d := range data {
 		t.Run(d.Name, func(t *testing.T) {
-			err := d.Article.Normalize(tagLimit)
+			err := d.Article.Normalize(tagLimit, nil)
 			if d.VerifyError != nil {
 				d.VerifyError(t, err)
 				return
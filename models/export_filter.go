@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExportFilter fields left empty do not filter.
+type ExportFilter struct {
+	From string
+	Tag  string
+	To   string
+}
+
+func (filter *ExportFilter) Invalid() error {
+	for _, date := range []string{filter.From, filter.To} {
+		if date == "" {
+			continue
+		}
+
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return errors.New("export dates must have format YYYY-MM-DD")
+		}
+	}
+
+	return nil
+}
+
+func (filter *ExportFilter) Normalize(tags TagPipeline) error {
+	if err := filter.Invalid(); err != nil {
+		return err
+	}
+
+	if filter.Tag == "" {
+		return nil
+	}
+
+	tag, err := tags.NormalizeName(filter.Tag)
+	filter.Tag = string(tag)
+	return err
+}
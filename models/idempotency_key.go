@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// IdempotencyKey with a zero Status is still being processed.
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+	Status      int
+	Response    []byte
+	Headers     map[string]string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "encoding/xml"
+
+type ImportReport struct {
+	XMLName xml.Name       `json:"-" xml:"import_report"`
+	Created int            `json:"created" xml:"created"`
+	Failed  int            `json:"failed" xml:"failed"`
+	Results []ImportResult `json:"results" xml:"results>result"`
+}
+
+type ImportResult struct {
+	Errors []string `json:"errors,omitempty" xml:"errors>error,omitempty"`
+	ID     int64    `json:"id,string,omitempty" xml:"id,omitempty"`
+	Index  int      `json:"index" xml:"index"`
+}
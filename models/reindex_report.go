@@ -0,0 +1,6 @@
+package models
+
+type ReindexReport struct {
+	DuplicateMappings int64 `json:"duplicate_mappings"`
+	OrphanTags        int64 `json:"orphan_tags"`
+}
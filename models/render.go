@@ -0,0 +1,65 @@
+package models
+
+import (
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/pkg/errors"
+	"github.com/russross/blackfriday"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var sanitizer = bluemonday.UGCPolicy()
+
+func (article *Article) invalidFormat() error {
+	switch strings.ToLower(article.Format) {
+	case "", FormatPlain, FormatMarkdown, FormatHTML:
+		return nil
+	default:
+		return errors.New("format must be one of plain, markdown or html")
+	}
+}
+
+func (article *Article) Render() {
+	article.Format = strings.ToLower(article.Format)
+	if article.Format == "" {
+		article.Format = FormatPlain
+	}
+
+	switch article.Format {
+	case FormatMarkdown:
+		article.BodyHTML = string(sanitizer.SanitizeBytes(blackfriday.MarkdownCommon([]byte(article.Body))))
+	case FormatHTML:
+		article.BodyHTML = sanitizer.Sanitize(article.Body)
+	default:
+		article.BodyHTML = plainHTML(article.Body)
+	}
+}
+
+// HTML renders articles stored before bodies were rendered.
+func (article *Article) HTML() string {
+	if article.BodyHTML == "" {
+		article.Render()
+	}
+
+	return article.BodyHTML
+}
+
+func plainHTML(text string) string {
+	var paragraphs []string
+
+	for _, paragraph := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraph = strings.Replace(html.EscapeString(paragraph), "\n", "<br>\n", -1)
+			paragraphs = append(paragraphs, "<p>"+paragraph+"</p>")
+		}
+	}
+
+	return strings.Join(paragraphs, "\n")
+}
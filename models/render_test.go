@@ -0,0 +1,52 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestRender(t *testing.T) {
+	data := []struct {
+		Name           string
+		Article        *models.Article
+		ExpectedFormat string
+		ExpectedHTML   string
+	}{
+		{
+			Name:           "Plain text is escaped",
+			Article:        &models.Article{Body: "a <b> & c\nd\n\ne"},
+			ExpectedFormat: models.FormatPlain,
+			ExpectedHTML:   "<p>a &lt;b&gt; &amp; c<br>\nd</p>\n<p>e</p>",
+		},
+		{
+			Name:           "Markdown is rendered",
+			Article:        &models.Article{Body: "# z1\n\n*z3* [link](https://example.com)", Format: "Markdown"},
+			ExpectedFormat: models.FormatMarkdown,
+			ExpectedHTML:   "<h1>z1</h1>\n\n<p><em>z3</em> <a href=\"https://example.com\" rel=\"nofollow\">link</a></p>\n",
+		},
+		{
+			Name:           "Markdown cannot inject scripts",
+			Article:        &models.Article{Body: "z3 <script>alert(1)</script> [x](javascript:alert(1))", Format: models.FormatMarkdown},
+			ExpectedFormat: models.FormatMarkdown,
+			ExpectedHTML:   "<p>z3  x</p>\n",
+		},
+		{
+			Name:           "HTML is sanitized",
+			Article:        &models.Article{Body: `<p onclick="steal()">z3</p><img src="x" onerror="steal()">`, Format: models.FormatHTML},
+			ExpectedFormat: models.FormatHTML,
+			ExpectedHTML:   `<p>z3</p><img src="x">`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			d.Article.Render()
+
+			assert.Equal(t, d.ExpectedFormat, d.Article.Format, "format")
+			assert.Equal(t, d.ExpectedHTML, d.Article.BodyHTML, "rendered body")
+		})
+	}
+}
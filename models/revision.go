@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Revision is the content of an article as one edit left it.
+type Revision struct {
+	XMLName   xml.Name  `json:"-" xml:"revision"`
+	ArticleID int64     `json:"article_id,string" xml:"article_id"`
+	Body      string    `json:"body" xml:"body"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	Date      string    `json:"date" xml:"date"`
+	Editor    string    `json:"editor,omitempty" xml:"editor,omitempty"`
+	Format    string    `json:"format" xml:"format"`
+	Number    int       `json:"number" xml:"number"`
+	Tags      []Tag     `json:"tags" xml:"tags>tag"`
+	Title     string    `json:"title" xml:"title"`
+}
+
+type Revisions struct {
+	XMLName   xml.Name   `json:"-" xml:"revisions"`
+	Revisions []Revision `json:"revisions" xml:"revision"`
+}
+
+// Restore keeps the article's identity, status and version.
+func (article *Article) Restore(revision *Revision) {
+	article.Title = revision.Title
+	article.Body = revision.Body
+	article.Date = revision.Date
+	article.Format = revision.Format
+	article.Tags = revision.Tags
+}
+
+type Change struct {
+	From string `json:"from" xml:"from"`
+	To   string `json:"to" xml:"to"`
+}
+
+// RevisionDiff leaves unchanged fields out and diffs the body line by line.
+type RevisionDiff struct {
+	XMLName     xml.Name `json:"-" xml:"diff"`
+	From        int      `json:"from" xml:"from,attr"`
+	To          int      `json:"to" xml:"to,attr"`
+	Title       *Change  `json:"title,omitempty" xml:"title,omitempty"`
+	Date        *Change  `json:"date,omitempty" xml:"date,omitempty"`
+	Format      *Change  `json:"format,omitempty" xml:"format,omitempty"`
+	TagsAdded   []Tag    `json:"tags_added,omitempty" xml:"tags_added>tag,omitempty"`
+	TagsRemoved []Tag    `json:"tags_removed,omitempty" xml:"tags_removed>tag,omitempty"`
+	Body        []string `json:"body,omitempty" xml:"body>line,omitempty"`
+}
+
+func Diff(from *Revision, to *Revision) *RevisionDiff {
+	diff := &RevisionDiff{
+		From:   from.Number,
+		To:     to.Number,
+		Title:  change(from.Title, to.Title),
+		Date:   change(from.Date, to.Date),
+		Format: change(from.Format, to.Format),
+	}
+
+	diff.TagsAdded = missingTags(to.Tags, from.Tags)
+	diff.TagsRemoved = missingTags(from.Tags, to.Tags)
+
+	if from.Body != to.Body {
+		diff.Body = diffLines(from.Body, to.Body)
+	}
+
+	return diff
+}
+
+func change(from string, to string) *Change {
+	if from == to {
+		return nil
+	}
+
+	return &Change{From: from, To: to}
+}
+
+func missingTags(tags []Tag, others []Tag) []Tag {
+	seen := make(map[Tag]bool, len(others))
+	for _, tag := range others {
+		seen[tag] = true
+	}
+
+	var missing []Tag
+	for _, tag := range tags {
+		if !seen[tag] {
+			missing = append(missing, tag)
+		}
+	}
+
+	return missing
+}
+
+func diffLines(from string, to string) []string {
+	dmp := diffmatchpatch.New()
+	fromChars, toChars, lines := dmp.DiffLinesToChars(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(fromChars, toChars, false), lines)
+
+	var result []string
+	for _, d := range diffs {
+		prefix := " "
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		}
+
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				result = append(result, prefix+strings.TrimSuffix(line, "\n"))
+			}
+		}
+	}
+
+	return result
+}
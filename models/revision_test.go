@@ -0,0 +1,36 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestDiff(t *testing.T) {
+	from := &models.Revision{Body: "a\nb\nc", Date: "2018-06-12", Format: "plain", Number: 1, Tags: []models.Tag{"sports", "music"}, Title: "z1"}
+	to := &models.Revision{Body: "a\nB\nc", Date: "2018-06-12", Format: "plain", Number: 3, Tags: []models.Tag{"sports", "news"}, Title: "z2"}
+
+	diff := models.Diff(from, to)
+
+	assert.Equal(t, 1, diff.From, "from")
+	assert.Equal(t, 3, diff.To, "to")
+	assert.Equal(t, &models.Change{From: "z1", To: "z2"}, diff.Title, "title")
+	assert.Nil(t, diff.Date, "unchanged date")
+	assert.Nil(t, diff.Format, "unchanged format")
+	assert.Equal(t, []models.Tag{"news"}, diff.TagsAdded, "tags added")
+	assert.Equal(t, []models.Tag{"music"}, diff.TagsRemoved, "tags removed")
+	assert.Equal(t, []string{" a", "-b", "+B", " c"}, diff.Body, "body")
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	revision := &models.Revision{Body: "a", Date: "2018-06-12", Number: 2, Tags: []models.Tag{"sports"}, Title: "z1"}
+
+	diff := models.Diff(revision, revision)
+
+	assert.Nil(t, diff.Title, "title")
+	assert.Empty(t, diff.TagsAdded, "tags added")
+	assert.Empty(t, diff.TagsRemoved, "tags removed")
+	assert.Empty(t, diff.Body, "body")
+}
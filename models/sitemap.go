@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type SitemapPage struct {
+	LastModified time.Time
+	Page         int
+}
+
+type SitemapEntry struct {
+	ID        int64
+	Slug      string
+	UpdatedAt time.Time
+}
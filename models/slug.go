@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rainycape/unidecode"
+)
+
+const maxSlugLength = 80
+
+// Slugify gives titles without any letters or digits the slug "article".
+func Slugify(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(unidecode.Unidecode(title)), func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+
+	slug := ""
+	for _, word := range words {
+		if len(slug)+len(word)+1 > maxSlugLength {
+			break
+		}
+
+		if slug != "" {
+			slug += "-"
+		}
+		slug += word
+	}
+
+	if slug == "" {
+		if len(words) > 0 {
+			return words[0][:maxSlugLength]
+		}
+
+		return "article"
+	}
+
+	return slug
+}
@@ -0,0 +1,55 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestSlugify(t *testing.T) {
+	data := []struct {
+		Name         string
+		Title        string
+		ExpectedSlug string
+	}{
+		{
+			Name:         "Words are lowercased and joined",
+			Title:        "  Hello, World! 2018 ",
+			ExpectedSlug: "hello-world-2018",
+		},
+		{
+			Name:         "Accents are stripped",
+			Title:        "Café déjà vu",
+			ExpectedSlug: "cafe-deja-vu",
+		},
+		{
+			Name:         "Other scripts are transliterated",
+			Title:        "Привет мир",
+			ExpectedSlug: "privet-mir",
+		},
+		{
+			Name:         "Titles without words",
+			Title:        "?!",
+			ExpectedSlug: "article",
+		},
+		{
+			Name:         "Long titles are cut between words",
+			Title:        strings.Repeat("football ", 20),
+			ExpectedSlug: strings.TrimSuffix(strings.Repeat("football-", 9), "-"),
+		},
+		{
+			Name:         "Long words are cut",
+			Title:        strings.Repeat("a", 100),
+			ExpectedSlug: strings.Repeat("a", 80),
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			assert.Equal(t, d.ExpectedSlug, models.Slugify(d.Title), "slug")
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+var transitions = map[string][]string{
+	StatusDraft:     {StatusDraft, StatusScheduled, StatusPublished, StatusArchived},
+	StatusScheduled: {StatusDraft, StatusScheduled, StatusPublished},
+	StatusPublished: {StatusPublished, StatusArchived},
+	StatusArchived:  {StatusArchived, StatusDraft, StatusPublished},
+}
+
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("article cannot move from %s to %s", e.From, e.To)
+}
+
+func (article *Article) invalidStatus() error {
+	if _, ok := transitions[article.Status]; article.Status != "" && !ok {
+		return errors.New("status must be one of draft, scheduled, published or archived")
+	}
+
+	if article.Status == StatusScheduled && article.PublishAt == nil {
+		return errors.New("scheduled articles need publish_at")
+	}
+
+	return nil
+}
+
+// Transition keeps from when the article asks for no status; new articles
+// start in any status.
+func (article *Article) Transition(from string) error {
+	if article.Status == "" {
+		article.Status = from
+	}
+
+	if from == "" {
+		if article.Status == "" {
+			article.Status = StatusPublished
+		}
+
+		return nil
+	}
+
+	for _, to := range transitions[from] {
+		if to == article.Status {
+			return nil
+		}
+	}
+
+	return &TransitionError{From: from, To: article.Status}
+}
+
+// Published treats articles stored before statuses existed as published.
+func (article *Article) Published() bool {
+	return article.Status == "" || article.Status == StatusPublished
+}
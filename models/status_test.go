@@ -0,0 +1,112 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+func TestTransition(t *testing.T) {
+	data := []struct {
+		Name           string
+		From           string
+		To             string
+		ExpectedStatus string
+		ExpectedError  string
+	}{
+		{
+			Name:           "New articles are published by default",
+			ExpectedStatus: models.StatusPublished,
+		},
+		{
+			Name:           "New articles may start as drafts",
+			To:             models.StatusDraft,
+			ExpectedStatus: models.StatusDraft,
+		},
+		{
+			Name:           "Updates keep the stored status",
+			From:           models.StatusScheduled,
+			ExpectedStatus: models.StatusScheduled,
+		},
+		{
+			Name:           "Drafts can be scheduled",
+			From:           models.StatusDraft,
+			To:             models.StatusScheduled,
+			ExpectedStatus: models.StatusScheduled,
+		},
+		{
+			Name:           "Published articles can be archived",
+			From:           models.StatusPublished,
+			To:             models.StatusArchived,
+			ExpectedStatus: models.StatusArchived,
+		},
+		{
+			Name:          "Published articles cannot go back to drafts",
+			From:          models.StatusPublished,
+			To:            models.StatusDraft,
+			ExpectedError: "article cannot move from published to draft",
+		},
+		{
+			Name:          "Scheduled articles cannot be archived",
+			From:          models.StatusScheduled,
+			To:            models.StatusArchived,
+			ExpectedError: "article cannot move from scheduled to archived",
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			article := &models.Article{Status: d.To}
+			err := article.Transition(d.From)
+
+			if d.ExpectedError != "" {
+				assert.EqualError(t, err, d.ExpectedError, "error")
+				return
+			}
+
+			assert.NoError(t, err, "error")
+			assert.Equal(t, d.ExpectedStatus, article.Status, "status")
+		})
+	}
+}
+
+func TestInvalidStatus(t *testing.T) {
+	publishAt := time.Date(2018, 6, 12, 10, 0, 0, 0, time.UTC)
+
+	data := []struct {
+		Name          string
+		Article       *models.Article
+		ExpectedError string
+	}{
+		{
+			Name:          "Unknown status",
+			Article:       &models.Article{Body: "z3", Date: "2018-06-12", Status: "deleted", Title: "z1"},
+			ExpectedError: "status must be one of draft, scheduled, published or archived",
+		},
+		{
+			Name:          "Scheduled without publish_at",
+			Article:       &models.Article{Body: "z3", Date: "2018-06-12", Status: models.StatusScheduled, Title: "z1"},
+			ExpectedError: "scheduled articles need publish_at",
+		},
+		{
+			Name:    "Scheduled with publish_at",
+			Article: &models.Article{Body: "z3", Date: "2018-06-12", PublishAt: &publishAt, Status: models.StatusScheduled, Title: "z1"},
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			err := d.Article.Invalid(3)
+
+			if d.ExpectedError != "" {
+				assert.EqualError(t, err, d.ExpectedError, "error")
+				return
+			}
+
+			assert.NoError(t, err, "error")
+		})
+	}
+}
@@ -16,19 +16,21 @@ func (tag *Tag) Invalid() error {
 	return nil
 }
 
+// uniqTags keeps the first occurrence of each tag, in the author's order.
 func uniqTags(tags []Tag) []Tag {
 	set := make(map[Tag]bool)
 	uniqTags := make([]Tag, 0, len(tags))
 
 	for _, tag := range tags {
-		if tag.Invalid() == nil {
-			lowTag := Tag(strings.ToLower(string(tag)))
-			set[lowTag] = true
+		if tag.Invalid() != nil {
+			continue
 		}
-	}
 
-	for tag := range set {
-		uniqTags = append(uniqTags, tag)
+		lowTag := Tag(strings.ToLower(string(tag)))
+		if !set[lowTag] {
+			set[lowTag] = true
+			uniqTags = append(uniqTags, lowTag)
+		}
 	}
 
 	return uniqTags
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TagAlias makes another name resolve to a canonical tag.
+type TagAlias struct {
+	XMLName   xml.Name  `json:"-" xml:"alias"`
+	Alias     string    `json:"alias" xml:"name"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	Tag       Tag       `json:"tag" xml:"tag"`
+}
+
+type TagAliases struct {
+	XMLName xml.Name   `json:"-" xml:"aliases"`
+	Aliases []TagAlias `json:"aliases" xml:"alias"`
+}
+
+func (alias *TagAlias) Normalize(tags TagPipeline) error {
+	name, err := tags.NormalizeName(alias.Alias)
+	if err != nil {
+		return err
+	}
+
+	if alias.Tag, err = tags.NormalizeName(string(alias.Tag)); err != nil {
+		return err
+	}
+
+	alias.Alias = string(name)
+	if alias.Alias == "" {
+		return errors.New("alias is empty")
+	}
+
+	if err = alias.Tag.Invalid(); err != nil {
+		return err
+	}
+
+	if alias.Alias == string(alias.Tag) {
+		return errors.New("a tag cannot be an alias of itself")
+	}
+
+	return nil
+}
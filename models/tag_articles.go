@@ -1,12 +1,15 @@
 package models
 
 import (
+	"encoding/xml"
+
 	"github.com/lib/pq"
 )
 
 type TagArticles struct {
-	Articles    pq.StringArray `json:"articles,omitempty"`
-	Count       int            `json:"count"`
-	RelatedTags pq.StringArray `json:"related_tags,omitempty"`
-	Tag         string         `json:"tag"`
+	XMLName     xml.Name       `json:"-" xml:"tag_articles"`
+	Articles    pq.StringArray `json:"articles,omitempty" xml:"articles>id,omitempty"`
+	Count       int            `json:"count" xml:"count"`
+	RelatedTags pq.StringArray `json:"related_tags,omitempty" xml:"related_tags>tag,omitempty"`
+	Tag         string         `json:"tag" xml:"tag"`
 }
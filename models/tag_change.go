@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	TagRenamed = "rename"
+	TagMerged  = "merge"
+)
+
+type TagChange struct {
+	XMLName   xml.Name  `json:"-" xml:"change"`
+	Action    string    `json:"action" xml:"action"`
+	Actor     string    `json:"actor,omitempty" xml:"actor,omitempty"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	From      string    `json:"from" xml:"from"`
+	ID        int64     `json:"id,string" xml:"id"`
+	To        string    `json:"to" xml:"to"`
+}
+
+type TagChanges struct {
+	XMLName xml.Name    `json:"-" xml:"tag_changes"`
+	Changes []TagChange `json:"changes" xml:"change"`
+}
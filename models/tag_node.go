@@ -0,0 +1,15 @@
+package models
+
+import "encoding/xml"
+
+type TagNode struct {
+	XMLName  xml.Name  `json:"-" xml:"tag"`
+	Children []TagNode `json:"children" xml:"children>tag"`
+	Name     Tag       `json:"name" xml:"name"`
+}
+
+// TagTree leaves out tags with neither a parent nor children.
+type TagTree struct {
+	XMLName xml.Name  `json:"-" xml:"tags"`
+	Tags    []TagNode `json:"tags" xml:"tag"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type TagNormalizationReport struct {
+	Merged   int      `json:"merged"`
+	Rejected []string `json:"rejected"`
+	Renamed  int      `json:"renamed"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TagNormalizer rewrites a tag into its canonical form, or rejects it.
+type TagNormalizer func(Tag) (Tag, error)
+
+// TagPipeline runs tags through a chain of normalizers, in order.
+type TagPipeline []TagNormalizer
+
+type TagRules struct {
+	// AllowedCharacters is the body of a regular expression character class.
+	AllowedCharacters string
+	BannedWords       []string
+	MaxLength         int
+}
+
+var tagNormalizers = map[string]func(TagRules) (TagNormalizer, error){
+	"trim": func(TagRules) (TagNormalizer, error) {
+		return func(tag Tag) (Tag, error) { return Tag(strings.TrimSpace(string(tag))), nil }, nil
+	},
+	"nfkc": func(TagRules) (TagNormalizer, error) {
+		return func(tag Tag) (Tag, error) { return Tag(norm.NFKC.String(string(tag))), nil }, nil
+	},
+	"casefold": func(TagRules) (TagNormalizer, error) {
+		return func(tag Tag) (Tag, error) { return Tag(cases.Fold().String(string(tag))), nil }, nil
+	},
+	"unaccent": func(TagRules) (TagNormalizer, error) {
+		return unaccentTag, nil
+	},
+	"hyphenate": func(TagRules) (TagNormalizer, error) {
+		return hyphenateTag, nil
+	},
+	"max-length": func(rules TagRules) (TagNormalizer, error) {
+		if rules.MaxLength <= 0 {
+			return nil, errors.New("max-length needs a positive maximum length")
+		}
+
+		return func(tag Tag) (Tag, error) {
+			if utf8.RuneCountInString(string(tag)) > rules.MaxLength {
+				return "", errors.Errorf("tag %q is longer than %d characters", tag, rules.MaxLength)
+			}
+
+			return tag, nil
+		}, nil
+	},
+	"charset": func(rules TagRules) (TagNormalizer, error) {
+		allowed, err := regexp.Compile("^[" + rules.AllowedCharacters + "]+$")
+		if err != nil || rules.AllowedCharacters == "" {
+			return nil, errors.Errorf("charset needs a valid character class, not %q", rules.AllowedCharacters)
+		}
+
+		return func(tag Tag) (Tag, error) {
+			if !allowed.MatchString(string(tag)) {
+				return "", errors.Errorf("tag %q may only contain the characters [%s]", tag, rules.AllowedCharacters)
+			}
+
+			return tag, nil
+		}, nil
+	},
+	"banned": func(rules TagRules) (TagNormalizer, error) {
+		banned := make(map[string]bool, len(rules.BannedWords))
+		for _, word := range rules.BannedWords {
+			banned[cases.Fold().String(strings.TrimSpace(word))] = true
+		}
+
+		return func(tag Tag) (Tag, error) {
+			for _, word := range strings.FieldsFunc(cases.Fold().String(string(tag)), isWordSeparator) {
+				if banned[word] {
+					return "", errors.Errorf("tag %q is not allowed", tag)
+				}
+			}
+
+			return tag, nil
+		}, nil
+	},
+}
+
+func NewTagPipeline(names []string, rules TagRules) (TagPipeline, error) {
+	pipeline := make(TagPipeline, 0, len(names))
+
+	for _, name := range names {
+		build, ok := tagNormalizers[strings.TrimSpace(name)]
+		if !ok {
+			return nil, errors.Errorf("unknown tag normalizer %q, use trim, nfkc, casefold, unaccent, hyphenate, "+
+				"max-length, charset or banned", name)
+		}
+
+		normalizer, err := build(rules)
+		if err != nil {
+			return nil, err
+		}
+
+		pipeline = append(pipeline, normalizer)
+	}
+
+	return pipeline, nil
+}
+
+// Normalize returns tags that end up empty as such, for the caller to drop.
+func (pipeline TagPipeline) Normalize(tag Tag) (Tag, error) {
+	var err error
+
+	for _, normalizer := range pipeline {
+		if tag == "" {
+			break
+		}
+
+		if tag, err = normalizer(tag); err != nil {
+			return "", err
+		}
+	}
+
+	return tag, nil
+}
+
+// NormalizeName normalizes a tag named in a request path or on the command line.
+func (pipeline TagPipeline) NormalizeName(name string) (Tag, error) {
+	tag, err := pipeline.Normalize(Tag(strings.TrimSpace(name)))
+	if err != nil {
+		return "", err
+	}
+
+	return Tag(strings.ToLower(string(tag))), nil
+}
+
+func unaccentTag(tag Tag) (Tag, error) {
+	unaccented, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), string(tag))
+	return Tag(unaccented), errors.Wrap(err, "failed to strip diacritics")
+}
+
+func hyphenateTag(tag Tag) (Tag, error) {
+	words := strings.FieldsFunc(string(tag), isWordSeparator)
+	return Tag(strings.Join(words, "-")), nil
+}
+
+func isWordSeparator(r rune) bool {
+	return r == '-' || unicode.IsSpace(r)
+}
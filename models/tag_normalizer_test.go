@@ -0,0 +1,160 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+var tagRules = models.TagRules{
+	AllowedCharacters: `\p{L}\p{M}\p{N}._+#-`,
+	BannedWords:       []string{"Spam"},
+	MaxLength:         12,
+}
+
+func TestNewTagPipeline(t *testing.T) {
+	data := []struct {
+		Name          string
+		Normalizers   []string
+		Rules         models.TagRules
+		ExpectedError string
+	}{
+		{
+			Name:        "Success",
+			Normalizers: []string{"trim", "nfkc", "casefold", "unaccent", "hyphenate", "max-length", "charset", "banned"},
+			Rules:       tagRules,
+		},
+		{
+			Name:          "Failure - unknown normalizer",
+			Normalizers:   []string{"trim", "stem"},
+			Rules:         tagRules,
+			ExpectedError: `unknown tag normalizer "stem", use trim, nfkc, casefold, unaccent, hyphenate, max-length, charset or banned`,
+		},
+		{
+			Name:          "Failure - no maximum length",
+			Normalizers:   []string{"max-length"},
+			ExpectedError: "max-length needs a positive maximum length",
+		},
+		{
+			Name:          "Failure - invalid character class",
+			Normalizers:   []string{"charset"},
+			Rules:         models.TagRules{AllowedCharacters: `\p{Nope}`},
+			ExpectedError: `charset needs a valid character class, not "\\p{Nope}"`,
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			pipeline, err := models.NewTagPipeline(d.Normalizers, d.Rules)
+
+			if d.ExpectedError != "" {
+				assert.EqualError(t, err, d.ExpectedError, "error")
+				return
+			}
+
+			assert.NoError(t, err, "error")
+			assert.Len(t, pipeline, len(d.Normalizers), "pipeline")
+		})
+	}
+}
+
+func TestTagPipelineNormalize(t *testing.T) {
+	data := []struct {
+		Name          string
+		Normalizers   []string
+		Tag           models.Tag
+		ExpectedTag   models.Tag
+		ExpectedError string
+	}{
+		{
+			Name:        "Success - empty pipeline",
+			Tag:         " New York ",
+			ExpectedTag: " New York ",
+		},
+		{
+			Name:        "Success - whitespace hyphenated",
+			Normalizers: []string{"trim", "casefold", "hyphenate"},
+			Tag:         "  New \t York - City ",
+			ExpectedTag: "new-york-city",
+		},
+		{
+			Name:        "Success - compatibility characters folded",
+			Normalizers: []string{"nfkc", "casefold"},
+			Tag:         "ＳＴＲＡßＥ",
+			ExpectedTag: "strasse",
+		},
+		{
+			Name:        "Success - diacritics stripped",
+			Normalizers: []string{"unaccent"},
+			Tag:         "café",
+			ExpectedTag: "cafe",
+		},
+		{
+			Name:        "Success - blank tag left empty",
+			Normalizers: []string{"trim", "max-length", "charset"},
+			Tag:         "   ",
+			ExpectedTag: "",
+		},
+		{
+			Name:          "Failure - too long",
+			Normalizers:   []string{"max-length"},
+			Tag:           "photographers",
+			ExpectedError: `tag "photographers" is longer than 12 characters`,
+		},
+		{
+			Name:          "Failure - character not allowed",
+			Normalizers:   []string{"charset"},
+			Tag:           "rock&roll",
+			ExpectedError: `tag "rock&roll" may only contain the characters [\p{L}\p{M}\p{N}._+#-]`,
+		},
+		{
+			Name:        "Success - allowed punctuation kept",
+			Normalizers: []string{"casefold", "charset"},
+			Tag:         "C#",
+			ExpectedTag: "c#",
+		},
+		{
+			Name:          "Failure - banned word",
+			Normalizers:   []string{"hyphenate", "banned"},
+			Tag:           "cheap SPAM",
+			ExpectedError: `tag "cheap-SPAM" is not allowed`,
+		},
+		{
+			Name:        "Success - banned word inside another word",
+			Normalizers: []string{"banned"},
+			Tag:         "spamalot",
+			ExpectedTag: "spamalot",
+		},
+	}
+
+	for _, d := range data {
+		t.Run(d.Name, func(t *testing.T) {
+			pipeline, err := models.NewTagPipeline(d.Normalizers, tagRules)
+			assert.NoError(t, err, "pipeline")
+
+			tag, err := pipeline.Normalize(d.Tag)
+
+			if d.ExpectedError != "" {
+				assert.EqualError(t, err, d.ExpectedError, "error")
+				return
+			}
+
+			assert.NoError(t, err, "error")
+			assert.Equal(t, d.ExpectedTag, tag, "tag")
+		})
+	}
+}
+
+func TestTagPipelineNormalizeName(t *testing.T) {
+	pipeline, err := models.NewTagPipeline([]string{"hyphenate", "charset"}, tagRules)
+	assert.NoError(t, err, "pipeline")
+
+	tag, err := pipeline.NormalizeName(" New York ")
+	assert.NoError(t, err, "error")
+	assert.Equal(t, models.Tag("new-york"), tag, "tag")
+
+	_, err = pipeline.NormalizeName("rock&roll")
+	assert.EqualError(t, err, `tag "rock&roll" may only contain the characters [\p{L}\p{M}\p{N}._+#-]`, "error")
+}
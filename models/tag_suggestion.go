@@ -0,0 +1,14 @@
+package models
+
+import "encoding/xml"
+
+type TagSuggestion struct {
+	XMLName xml.Name `json:"-" xml:"tag"`
+	Count   int      `json:"count" xml:"count"`
+	Name    Tag      `json:"name" xml:"name"`
+}
+
+type TagSuggestions struct {
+	XMLName xml.Name        `json:"-" xml:"tags"`
+	Tags    []TagSuggestion `json:"tags" xml:"tag"`
+}
@@ -0,0 +1,7 @@
+package providers
+
+import "time"
+
+type ArticlePublisher interface {
+	PublishScheduled(time.Time) (int64, error)
+}
@@ -0,0 +1,7 @@
+package providers
+
+import "time"
+
+type ArticlePurger interface {
+	PurgeDeleted(time.Time) (int64, error)
+}
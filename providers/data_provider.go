@@ -3,7 +3,39 @@ package providers
 import "github.com/eve-qunliu/articles/models"
 
 type DataProvider interface {
+	ArticleExporter
+	ArticleHistorian
+	ArticleImporter
+	ArticleSitemapper
+	TagSuggester
 	CreateArticle(*models.Article) error
+	DeleteArticle(string, int64) error
 	FindArticle(string) (*models.Article, error)
-	FindTag(string, string) (*models.TagArticles, error)
+	FindArticleBySlug(string) (*models.Article, error)
+	FindTag(string, string, bool) (*models.TagArticles, error)
+	LatestArticles(string, int) ([]*models.Article, error)
+	RestoreArticle(string) error
+	UpdateArticle(*models.Article) error
+}
+
+type ArticleImporter interface {
+	CreateArticles([]*models.Article) error
+}
+
+type ArticleExporter interface {
+	ExportArticles(models.ExportFilter, func(*models.Article) error) error
+}
+
+type ArticleSitemapper interface {
+	SitemapPages(pageSize int) ([]models.SitemapPage, error)
+	SitemapEntries(page int, pageSize int, fn func(models.SitemapEntry) error) error
+}
+
+type ArticleHistorian interface {
+	ArticleRevisions(string) ([]models.Revision, error)
+	ArticleRevision(string, int) (*models.Revision, error)
+}
+
+type TagSuggester interface {
+	SuggestTags(prefix string, since string, limit int) ([]models.TagSuggestion, error)
 }
@@ -0,0 +1,10 @@
+package providers
+
+import "github.com/pkg/errors"
+
+var (
+	// ErrNotFound is returned by write operations when the target does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write is conditioned on a stale version.
+	ErrConflict = errors.New("version conflict")
+)
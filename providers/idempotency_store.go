@@ -0,0 +1,15 @@
+package providers
+
+import (
+	"time"
+
+	"github.com/eve-qunliu/articles/models"
+)
+
+type IdempotencyStore interface {
+	DeleteIdempotencyKey(string) error
+	FindIdempotencyKey(string) (*models.IdempotencyKey, error)
+	PurgeIdempotencyKeys(time.Time) (int64, error)
+	ReserveIdempotencyKey(*models.IdempotencyKey, time.Time) (bool, error)
+	SaveIdempotencyKey(*models.IdempotencyKey) error
+}
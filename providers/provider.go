@@ -0,0 +1,7 @@
+package providers
+
+type Provider interface {
+	DataProvider
+	IdempotencyStore
+	TagAdmin
+}
@@ -0,0 +1,24 @@
+package providers
+
+import "github.com/eve-qunliu/articles/models"
+
+type TagAdmin interface {
+	TagAliaser
+	TagHierarchy
+	MergeTags(string, string, string) error
+	Reindex() (*models.ReindexReport, error)
+	RenameTag(string, string, string) error
+	TagAuditLog(int) ([]models.TagChange, error)
+}
+
+type TagAliaser interface {
+	DeleteTagAlias(string) error
+	FindTagAlias(string) (*models.TagAlias, error)
+	SaveTagAlias(*models.TagAlias) error
+	TagAliases() ([]models.TagAlias, error)
+}
+
+type TagHierarchy interface {
+	SetTagParent(string, string) error
+	TagTree() ([]models.TagNode, error)
+}